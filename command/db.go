@@ -0,0 +1,22 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Local database management",
+	Long: `Import, export and snapshots of the local project database.
+The database container (MySQL, MariaDB or PostgreSQL) is determined by the project variables.`,
+	ValidArgs: []string{"import", "export", "snapshot"},
+}
+
+func dbCommand() *cobra.Command {
+	dbCmd.AddCommand(
+		dbImportCommand(),
+		dbExportCommand(),
+		dbSnapshotCommand(),
+	)
+	return dbCmd
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/project"
+	"github.com/spf13/cobra"
+)
+
+func dbExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Export database dump",
+		Long: `Export the local database into a file.
+The file is compressed according to the extension: .sql.gz (gzip), .sql.zst (zstd), .sql without compression.
+For PostgreSQL the .dump extension creates a custom format dump.
+By default, the dump is saved to the project directory with the current date in the name.`,
+		Example: "dl db export\ndl db export dump.sql.zst",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return dbExportRun(args)
+		},
+	}
+	return cmd
+}
+
+func dbExportRun(args []string) error {
+	project.LoadEnv()
+
+	path := fmt.Sprintf("%s-%s.sql.gz", project.Env.GetString("NETWORK_NAME"), time.Now().Format("20060102-150405"))
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	err := project.UpDbContainer()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	err = progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		return project.ExportFile(ctx, path)
+	}, os.Stdout, "Export")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Database exported to %s\n", path)
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/project"
+	"github.com/spf13/cobra"
+)
+
+func dbImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import database dump",
+		Long: `Import a dump file into the local database container.
+Supported formats: .sql, .sql.gz, .sql.zst and PostgreSQL custom format (pg_dump -Fc).
+The compression is detected by the file content.`,
+		Example: "dl db import dump.sql\ndl db import dump.sql.gz",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return dbImportRun(args[0])
+		},
+	}
+	return cmd
+}
+
+func dbImportRun(path string) error {
+	project.LoadEnv()
+
+	_, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	err = project.UpDbContainer()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	err = progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		return project.ImportFile(ctx, path)
+	}, os.Stdout, "Import")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("All done")
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/project"
+	"github.com/spf13/cobra"
+)
+
+var dbSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Local database snapshots",
+	Long: `Saving and restoring snapshots of the local database, for example before running a risky migration.
+Snapshots are stored in the dl config directory separately for each project (NETWORK_NAME).`,
+	ValidArgs: []string{"create", "list", "restore", "delete"},
+}
+
+func dbSnapshotCommand() *cobra.Command {
+	dbSnapshotCmd.AddCommand(
+		dbSnapshotCreateCommand(),
+		dbSnapshotListCommand(),
+		dbSnapshotRestoreCommand(),
+		dbSnapshotDeleteCommand(),
+	)
+	return dbSnapshotCmd
+}
+
+func dbSnapshotCreateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "create [name]",
+		Short:   "Create snapshot",
+		Long:    `Create a snapshot of the local database. By default, the current date is used as the name.`,
+		Example: "dl db snapshot create\ndl db snapshot create before-migration",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return dbSnapshotCreateRun(args)
+		},
+	}
+	return cmd
+}
+
+func dbSnapshotCreateRun(args []string) error {
+	project.LoadEnv()
+
+	name := time.Now().Format("20060102-150405")
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	err := project.UpDbContainer()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	err = progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		return project.CreateSnapshot(ctx, name)
+	}, os.Stdout, "Snapshot")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Snapshot %s created\n", name)
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package command
+
+import (
+	"github.com/local-deploy/dl/project"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+func dbSnapshotDeleteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "delete <name>",
+		Aliases: []string{"rm"},
+		Short:   "Delete snapshot",
+		Long:    `Delete the local database snapshot.`,
+		Example: "dl db snapshot delete before-migration",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return dbSnapshotDeleteRun(args[0])
+		},
+	}
+	return cmd
+}
+
+func dbSnapshotDeleteRun(name string) error {
+	project.LoadEnv()
+
+	err := project.DeleteSnapshot(name)
+	if err != nil {
+		return err
+	}
+
+	pterm.FgGreen.Printfln("Snapshot %s deleted", name)
+
+	return nil
+}
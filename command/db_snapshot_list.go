@@ -0,0 +1,46 @@
+package command
+
+import (
+	"github.com/local-deploy/dl/project"
+	"github.com/local-deploy/dl/utils"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+func dbSnapshotListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List snapshots",
+		Long:    `List of the local database snapshots of the current project.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return dbSnapshotListRun()
+		},
+	}
+	return cmd
+}
+
+func dbSnapshotListRun() error {
+	project.LoadEnv()
+
+	snapshots, err := project.Snapshots()
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		pterm.FgYellow.Println("No snapshots found")
+		return nil
+	}
+
+	data := [][]string{{"Name", "Created", "Size"}}
+	for _, snapshot := range snapshots {
+		data = append(data, []string{
+			snapshot.Name,
+			snapshot.Created.Format("2006-01-02 15:04:05"),
+			utils.HumanSize(float64(snapshot.Size)),
+		})
+	}
+
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/project"
+	"github.com/spf13/cobra"
+)
+
+func dbSnapshotRestoreCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "restore <name>",
+		Short:   "Restore snapshot",
+		Long:    `Replace the local database with the data from the snapshot.`,
+		Example: "dl db snapshot restore before-migration",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return dbSnapshotRestoreRun(args[0])
+		},
+	}
+	return cmd
+}
+
+func dbSnapshotRestoreRun(name string) error {
+	project.LoadEnv()
+
+	err := project.UpDbContainer()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	err = progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		return project.RestoreSnapshot(ctx, name)
+	}, os.Stdout, "Restore")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Snapshot %s restored\n", name)
+
+	return nil
+}
@@ -2,28 +2,39 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"strings"
-	"sync"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/docker/compose/v2/pkg/progress"
 	"github.com/local-deploy/dl/project"
 	"github.com/local-deploy/dl/utils/client"
-	"github.com/local-deploy/dl/utils/docker"
 	"github.com/local-deploy/dl/utils/teleport"
-	"github.com/pterm/pterm"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
 var (
-	database      bool
-	files         bool
-	override      []string
-	tables        []string
-	pullWaitGroup sync.WaitGroup
-	sshClient     *client.Client
+	database  bool
+	files     bool
+	override  []string
+	tables    []string
+	stream    bool
+	syncFiles bool
+	prune     bool
+	source    string
+	plan      bool
+	remote    client.Transport
+	dumpSize  int64
+	filesSize int64
+	maxAge    time.Duration
+	// cachedDump dump from the cache imported instead of the server database
+	cachedDump *project.DumpCacheEntry
 )
 
 func deployCommand() *cobra.Command {
@@ -37,154 +48,321 @@ If you specify a flag, for example -d, only the database will be downloaded.
 Directories that are downloaded by default
 Bitrix CMS: "bitrix"
 WordPress: "wp-admin" and "wp-includes"
-Laravel: only the database is downloaded`,
-		RunE: func(_ *cobra.Command, _ []string) error {
+Laravel: only the database is downloaded, the local .env file is updated (DB_*, APP_URL, REDIS_HOST, CACHE_DRIVER)
+
+For WordPress, the production site address is replaced with NIP_DOMAIN in the imported database
+(including serialized data) and in wp-config.php (WP_HOME, WP_SITEURL).
+
+The database engine is determined by the project variables:
+PostgreSQL is used if only POSTGRES_VERSION is set, otherwise MySQL/MariaDB.
+
+By default, the database dump and the files archive are created on the server, downloaded
+and then imported. With the --stream flag, the dump is imported and the archive is extracted
+on the fly from the SSH session, free space on the server is not required.
+Several servers (production, staging, etc.) are defined with the SOURCE_<NAME>_ prefix,
+for example SOURCE_STAGE_SERVER, SOURCE_STAGE_CATALOG_SRV, SOURCE_STAGE_MYSQL_PASSWORD_SRV.
+The source is selected with the --from flag or the DEPLOY_SOURCE variable, the source variables
+replace the default ones, the name must not contain underscores. The server, the catalog and the database
+accesses are not inherited from the default server: SOURCE_<NAME>_SERVER (or _TELEPORT) and
+SOURCE_<NAME>_CATALOG_SRV are required, the database accesses are determined on the source server
+if they are not set. The SSH settings (SSH_KEY, SSH_HOST_KEY_POLICY, etc.) are inherited.
+
+SERVER may be a Host alias from ~/.ssh/config: HostName, User, Port, IdentityFile and ProxyJump
+(one or more jump hosts) are taken from it if they are not set in the project variables.
+Keys of the SSH agent (SSH_AUTH_SOCK) are tried first, then the SSH_KEY keys: a comma separated
+list of file names in ~/.ssh or absolute paths (id_rsa by default).
+
+If the TELEPORT variable is set (login:node), the server is accessed with "tsh ssh" and "tsh scp"
+instead of SSH, the --sync flag is not available in this mode.
+
+Unknown server keys are handled by SSH_HOST_KEY_POLICY: "ask" (default) requests confirmation,
+"accept-new" adds the key without confirmation (for scripts), "strict" refuses to connect.
+A changed key is always refused. SSH_KNOWN_HOSTS sets a project known hosts file
+instead of ~/.ssh/known_hosts.
+
+The deploy is stopped with Ctrl-C: the dump and the archive are deleted on the server and locally,
+files are moved to BACKEND_ROOT only from a completely extracted archive.
+
+Before creating files on the server, the free space in CATALOG_SRV is compared with the size
+of the table data and the downloaded paths, the deploy is stopped if the space is insufficient.
+
+With the --sync flag, files are synchronized incrementally over SFTP: only new and changed
+(by size and modification time) files are downloaded, EXCLUDED_FILES are honoured.
+The --delete flag additionally removes local files that no longer exist on the server.
+
+FILTERED_TABLES limits the dumped rows of large tables while their schema is kept: "table:condition"
+entries separated by semicolons, for example "b_event_log:ID > (max-10000)" dumps the last 10000 ids
+(the condition is passed to mysqldump --where, PostgreSQL dumps are made in plain SQL with COPY of the rows).
+
+Personal data can be masked after the import: ANONYMIZE=true applies the built-in profile
+of the framework (Bitrix, WordPress, Laravel), ANONYMIZE_PROFILE sets the path to a custom YAML profile.
+The table prefix of WordPress is detected. A table of the profile missing in the database is an error.
+If the anonymization fails, the imported data is deleted from the local database.
+
+With the --max-age flag (or the DUMP_CACHE_MAX_AGE variable), the downloaded dump is saved in the dump cache
+(~/.config/dl/dump-cache) shared by all projects, and the next deploys of the same server, catalog
+and tables import the cached dump instead of dumping the server database while it is not older
+than the duration. The dump is not cached if the anonymization is enabled.
+The cache is managed with "dl deploy cache".
+
+The --plan flag connects to the server and shows the database accesses (the password is masked),
+the tables with their sizes, the excluded tables, the downloaded paths with their sizes and the local
+files and configs that would be changed, and how they are transferred with the --stream, --sync
+and --delete flags. Nothing is written on the server or locally, unknown server keys accepted
+by SSH_HOST_KEY_POLICY are not added to the known hosts file.
+
+The "dl deploy push" command uploads the local database and files back to the server,
+see "dl deploy push --help". Each deploy is recorded in the project history, see "dl deploy history".`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if prune && !syncFiles {
+				return errors.New("the --delete flag is used only with --sync")
+			}
+			// The errors of the deploy are not errors of the usage
+			cmd.SilenceUsage = true
+			if plan {
+				return deployPlanRun()
+			}
 			return deployRun()
 		},
-		Example:   "dl deploy\ndl deploy -d\ndl deploy -d -t b_user,b_file\ndl deploy -f\ndl deploy -f -o bitrix,upload",
-		ValidArgs: []string{"--database", "--files", "--override"},
+		Example:   "dl deploy\ndl deploy -d\ndl deploy -d -s\ndl deploy -d -t b_user,b_file\ndl deploy -f\ndl deploy -f -o bitrix,upload\ndl deploy -f --sync --delete\ndl deploy --from stage\ndl deploy --plan\ndl deploy -d --max-age 6h",
+		ValidArgs: []string{"--database", "--files", "--override", "--stream", "--sync", "--delete", "--from", "--plan", "--max-age"},
 	}
 	cmd.Flags().BoolVarP(&database, "database", "d", false, "Dump only database from server")
 	cmd.Flags().BoolVarP(&files, "files", "f", false, "Download only files from server")
 	cmd.Flags().StringSliceVarP(&override, "override", "o", nil, "Override downloaded files (comma separated values)")
 	cmd.Flags().StringSliceVarP(&tables, "tables", "t", nil, "Dump only specified tables (comma separated values)")
+	cmd.Flags().BoolVarP(&stream, "stream", "s", false, "Import the database and extract files directly from the SSH session without creating files on the server")
+	cmd.Flags().BoolVar(&syncFiles, "sync", false, "Download only new and changed files")
+	cmd.Flags().BoolVar(&prune, "delete", false, "Delete local files missing on the server (with --sync)")
+	cmd.Flags().StringVar(&source, "from", "", "Deploy source name (SOURCE_<NAME>_* variables)")
+	cmd.Flags().BoolVar(&plan, "plan", false, "Show what would be downloaded and changed without writing anything")
+	cmd.Flags().DurationVar(&maxAge, "max-age", 0, "Import the cached dump if it is not older than the duration (6h, 30m), new dumps are saved in the cache")
+	cmd.AddCommand(
+		deployPushCommand(),
+		deployHistoryCommand(),
+		deployCacheCommand(),
+	)
 	return cmd
 }
 
 func deployRun() error {
-	ctx := context.Background()
+	// Ctrl-C stops the deploy, the temporary files are deleted on the server and locally
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !database && !files {
+		database = true
+		files = true
+	}
+
+	start := time.Now()
 	err := progress.RunWithTitle(ctx, deployService, os.Stdout, "Deploy")
+	addHistory(start, err)
+
+	// The exit code is not zero, so that scripts and CI detect the failed or interrupted deploy
+	if errors.Is(err, context.Canceled) {
+		return errors.New("deploy cancelled")
+	}
 	if err != nil {
-		fmt.Println("Something went wrong...")
-		return nil
+		return fmt.Errorf("deploy failed: %w", err)
 	}
 
 	fmt.Println("All done")
 
-	showSpecificInfo()
+	if fw := project.GetFramework(deployFramework()); fw != nil {
+		fw.PrintInfo()
+	}
 
 	return nil
 }
 
-// showProjectInfo Display specific FW info
-func showSpecificInfo() {
-	if sshClient.Config.FwType == "wordpress" {
-		n := project.Env.GetString("NIP_DOMAIN")
-		pterm.Println()
-		pterm.FgYellow.Println("Please specify the domain in the wp-config.php file:")
-		//goland:noinspection HttpUrlsUsage
-		pterm.FgDefault.Printfln("define('WP_HOME', 'http://%s');\ndefine('WP_SITEURL', 'http://%s');", n, n)
-	}
-}
-
 func deployService(ctx context.Context) error {
 	w := progress.ContextWriter(ctx)
 
 	project.LoadEnv()
 
-	var err error
+	err := project.UseSource(source)
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent("Deploy source", fmt.Sprint(err)))
+		return err
+	}
 
-	if len(project.Env.GetString("TELEPORT")) > 0 {
-		sshClient = &client.Client{Config: &client.Config{FwType: "bitrix"}}
-		fmt.Println("Deploy using Teleport")
-		return teleport.DeployTeleport(ctx, database, files, override, tables)
+	if maxAge == 0 {
+		maxAge = project.Env.GetDuration("DUMP_CACHE_MAX_AGE")
+	}
+	if database && maxAge > 0 {
+		cachedDump, err = project.FindCachedDump(tables, maxAge)
+		if err != nil {
+			logrus.Infof("Failed to read the dump cache: %s", err)
+		}
+	}
+
+	// The server is not needed if only the cached dump is imported
+	if cachedDump != nil && !files {
+		err = project.UpDbContainer()
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent("Import failed", fmt.Sprint(err)))
+			return err
+		}
+		dumpSize, err = project.ImportCachedDump(ctx, cachedDump)
+		return err
 	}
 
-	sshClient, err = getClient()
+	t, err := getTransport(ctx)
 	if err != nil {
 		w.Event(progress.ErrorMessageEvent("Failed to connect", fmt.Sprint(err)))
 		return err
 	}
+	remote = t
 
 	// Defer closing the network connection.
-	defer func(client *client.Client) {
-		err = client.Close()
-		if err != nil {
-			return
-		}
-	}(sshClient)
+	if sshClient, ok := remote.(*client.Client); ok {
+		defer func(client *client.Client) {
+			_ = client.Close()
+		}(sshClient)
+	}
 
-	sshClient.Config.FwType, err = detectFw()
+	fw, err := project.SSHClient{Transport: remote}.DetectFramework()
 	if err != nil {
 		w.Event(progress.ErrorMessageEvent("Detect FW", fmt.Sprint(err)))
 		return err
 	}
+	if fw != nil {
+		remote.Settings().FwType = fw.Name()
+	}
 
-	if !database && !files {
-		database = true
-		files = true
+	if database {
+		err = project.UpDbContainer()
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent("Import failed", fmt.Sprint(err)))
+			return err
+		}
 	}
 
+	// The dump and the archive are created on the server at the same time, the free space is checked for both
+	spaceChecked := database && files && cachedDump == nil && !stream && !syncFiles
+	if spaceChecked {
+		err = project.CheckDeploySpace(remote, tables, override)
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent("Free space", fmt.Sprint(err)))
+			return err
+		}
+	}
+
+	// The first error cancels the other part of the deploy
+	g, gctx := errgroup.WithContext(ctx)
+
 	if files {
-		pullWaitGroup.Add(1)
-		go startFiles(ctx, sshClient)
+		g.Go(func() (err error) {
+			filesSize, err = project.CopyFiles(gctx, remote, project.FilesOptions{Override: override, Sync: syncFiles, Delete: prune,
+				Stream: stream, SpaceChecked: spaceChecked})
+			return err
+		})
 	}
 
 	if database {
-		err = docker.UpDbContainer()
-		if err != nil {
-			w.Event(progress.ErrorMessageEvent("Import failed", fmt.Sprint(err)))
+		g.Go(func() (err error) {
+			if cachedDump != nil {
+				dumpSize, err = project.ImportCachedDump(gctx, cachedDump)
+				return err
+			}
+			dumpSize, err = project.DumpDB(gctx, remote, project.DumpOptions{Tables: tables, Stream: stream, Cache: maxAge > 0,
+				SpaceChecked: spaceChecked})
 			return err
-		}
-		pullWaitGroup.Add(1)
-		go startDump(ctx, sshClient)
+		})
 	}
 
-	pullWaitGroup.Wait()
+	err = g.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
 	return err
 }
 
-func getClient() (c *client.Client, err error) {
-	server := &client.Config{
-		Addr:             project.Env.GetString("SERVER"),
-		Key:              project.Env.GetString("SSH_KEY"),
-		UseKeyPassphrase: project.Env.GetBool("ASK_KEY_PASSPHRASE"),
-		UsePassword:      project.Env.GetBool("USE_SSH_PASS"),
-		User:             project.Env.GetString("USER_SRV"),
-		Port:             project.Env.GetUint("PORT_SRV"),
-		Catalog:          project.Env.GetString("CATALOG_SRV"),
+// deployFramework framework of the deployed site, detected on the server or saved with the cached dump
+func deployFramework() string {
+	if remote != nil {
+		return remote.Settings().FwType
+	}
+	if cachedDump != nil {
+		return cachedDump.Framework
 	}
-	logrus.Infof("SSH client connect %v", fmt.Sprint(server))
-	c, err = client.NewClient(server)
-	return
-}
 
-func startFiles(ctx context.Context, c *client.Client) {
-	defer pullWaitGroup.Done()
-	project.CopyFiles(ctx, c, override)
+	return ""
 }
 
-func startDump(ctx context.Context, c *client.Client) {
-	defer pullWaitGroup.Done()
-	project.DumpDB(ctx, c, tables)
-}
+// addHistory Recording the deploy in the project history
+func addHistory(start time.Time, deployErr error) {
+	record := project.DeployRecord{
+		Time:      start,
+		Source:    project.CurrentSource(),
+		Server:    project.Env.GetString("SERVER"),
+		Catalog:   project.Env.GetString("CATALOG_SRV"),
+		Database:  database,
+		Files:     files,
+		Tables:    tables,
+		DumpSize:  dumpSize,
+		Cached:    cachedDump != nil,
+		FilesSize: filesSize,
+		Duration:  time.Since(start).Round(time.Second),
+		Status:    project.DeploySuccess,
+	}
 
-func detectFw() (string, error) {
-	ls := strings.Join([]string{"cd", sshClient.Config.Catalog, "&&", "ls"}, " ")
-	logrus.Infof("Run command: %s", ls)
-	out, err := sshClient.Run(ls)
-	if err != nil {
-		return "", err
+	record.Framework = deployFramework()
+	if remote != nil && len(record.Server) == 0 {
+		record.Server = remote.Settings().Addr
 	}
 
-	logrus.Info("Detect Framework")
-	if strings.Contains(string(out), "bitrix") {
-		fmt.Println("Bitrix CMS detected")
-		return "bitrix", nil
+	if files {
+		record.Paths = override
+		if fw := project.GetFramework(record.Framework); fw != nil && len(override) == 0 {
+			record.Paths = fw.Paths()
+		}
 	}
 
-	if strings.Contains(string(out), "wp-config.php") {
-		fmt.Println("WordPress CMS detected")
-		return "wordpress", nil
+	switch {
+	case errors.Is(deployErr, context.Canceled):
+		record.Status = project.DeployCancelled
+	case deployErr != nil:
+		record.Status = project.DeployFailed
+		record.Error = deployErr.Error()
 	}
 
-	if strings.Contains(string(out), "artisan") {
-		fmt.Println("Laravel FW detected")
-		return "laravel", nil
+	err := project.AddHistory(record)
+	if err != nil {
+		logrus.Infof("Failed to add the deploy to history: %s", err)
 	}
+}
 
-	logrus.Errorf("Output of ls: %s", string(out))
+// getTransport Teleport client if the TELEPORT variable is set, otherwise SSH client
+func getTransport(ctx context.Context) (client.Transport, error) {
+	if len(project.Env.GetString("TELEPORT")) > 0 {
+		fmt.Println("Deploy using Teleport")
+		return teleport.NewClient(ctx, project.Env.GetString("TELEPORT"), project.Env.GetString("CATALOG_SRV"))
+	}
 
-	return "", nil
+	return getClient()
+}
+
+func getClient() (c *client.Client, err error) {
+	server := &client.Config{
+		Addr:             project.Env.GetString("SERVER"),
+		Key:              project.Env.GetString("SSH_KEY"),
+		UseKeyPassphrase: project.Env.GetBool("ASK_KEY_PASSPHRASE"),
+		UsePassword:      project.Env.GetBool("USE_SSH_PASS"),
+		User:             project.Env.GetString("USER_SRV"),
+		Port:             project.Env.GetUint("PORT_SRV"),
+		Catalog:          project.Env.GetString("CATALOG_SRV"),
+		HostKeyPolicy:    project.Env.GetString("SSH_HOST_KEY_POLICY"),
+		KnownHostsFile:   project.Env.GetString("SSH_KNOWN_HOSTS"),
+		// The plan writes nothing locally, including the known hosts
+		ReadOnlyKnownHosts: plan,
+	}
+	if len(server.KnownHostsFile) > 0 && !filepath.IsAbs(server.KnownHostsFile) {
+		server.KnownHostsFile = filepath.Join(project.Env.GetString("PWD"), server.KnownHostsFile)
+	}
+	logrus.Infof("SSH client connect %v", fmt.Sprint(server))
+	c, err = client.NewClient(server)
+	return
 }
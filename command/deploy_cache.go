@@ -0,0 +1,22 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var deployCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Cached database dumps",
+	Long: `Dumps of the server databases saved by "dl deploy --max-age".
+The cache is stored in the dl config directory and is shared by all projects: the dump is reused
+by the projects and worktrees of the same server, catalog and tables.`,
+	ValidArgs: []string{"list", "clear"},
+}
+
+func deployCacheCommand() *cobra.Command {
+	deployCacheCmd.AddCommand(
+		deployCacheListCommand(),
+		deployCacheClearCommand(),
+	)
+	return deployCacheCmd
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"time"
+
+	"github.com/local-deploy/dl/project"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var clearOlderThan time.Duration
+
+func deployCacheClearCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "clear",
+		Short:   "Delete cached dumps",
+		Long:    `Delete the cached database dumps of all projects, or only the dumps older than the duration.`,
+		Example: "dl deploy cache clear\ndl deploy cache clear --older-than 24h",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return deployCacheClearRun()
+		},
+	}
+	cmd.Flags().DurationVar(&clearOlderThan, "older-than", 0, "Delete only the dumps older than the duration")
+	return cmd
+}
+
+func deployCacheClearRun() error {
+	count, err := project.ClearDumpCache(clearOlderThan)
+	if err != nil {
+		return err
+	}
+
+	pterm.FgGreen.Printfln("Deleted cached dumps: %d", count)
+
+	return nil
+}
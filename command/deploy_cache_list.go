@@ -0,0 +1,53 @@
+package command
+
+import (
+	"strings"
+	"time"
+
+	"github.com/local-deploy/dl/project"
+	"github.com/local-deploy/dl/utils"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+func deployCacheListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List cached dumps",
+		Long:    `List of the cached database dumps of all projects, the newest first.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return deployCacheListRun()
+		},
+	}
+	return cmd
+}
+
+func deployCacheListRun() error {
+	entries, err := project.DumpCache()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		pterm.FgYellow.Println("No cached dumps found")
+		return nil
+	}
+
+	data := [][]string{{"Server", "Database", "Tables", "Created", "Size"}}
+	for _, entry := range entries {
+		tables := "all"
+		if len(entry.Tables) > 0 {
+			tables = strings.Join(entry.Tables, ", ")
+		}
+		data = append(data, []string{
+			entry.Server + ":" + entry.Catalog,
+			entry.Database,
+			tables,
+			utils.FormatAgo(entry.Created, time.Now()),
+			utils.HumanSize(float64(entry.Size)),
+		})
+	}
+
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
@@ -0,0 +1,100 @@
+package command
+
+import (
+	"strings"
+	"time"
+
+	"github.com/local-deploy/dl/project"
+	"github.com/local-deploy/dl/utils"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var historyLimit int
+
+func deployHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Deploy history of the project",
+		Long: `List of the deploys of the current project, the newest first: the time, the source and the server,
+the downloaded parts, the transferred size, the duration and the result.
+The history is stored in the dl config directory (~/.config/dl/history).`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return deployHistoryRun()
+		},
+		Example: "dl deploy history\ndl deploy history -n 5",
+	}
+	cmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of the shown deploys (0 shows all)")
+	return cmd
+}
+
+func deployHistoryRun() error {
+	project.LoadEnv()
+
+	records, err := project.History()
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		pterm.FgYellow.Println("No deploys found")
+		return nil
+	}
+
+	if historyLimit > 0 && len(records) > historyLimit {
+		records = records[:historyLimit]
+	}
+
+	data := [][]string{{"Date", "Source", "Framework", "Downloaded", "Size", "Duration", "Status"}}
+	for _, record := range records {
+		data = append(data, []string{
+			record.Time.Local().Format("2006-01-02 15:04:05"),
+			historySource(record),
+			record.Framework,
+			historyParts(record),
+			utils.HumanSize(float64(record.DumpSize + record.FilesSize)),
+			record.Duration.Round(time.Second).String(),
+			historyStatus(record),
+		})
+	}
+
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+// historySource source name and the server with the site directory
+func historySource(record project.DeployRecord) string {
+	server := record.Server + ":" + record.Catalog
+	if len(record.Source) > 0 {
+		return record.Source + " (" + server + ")"
+	}
+
+	return server
+}
+
+// historyParts downloaded database tables and paths
+func historyParts(record project.DeployRecord) string {
+	var parts []string
+	if record.Database {
+		db := "db"
+		if len(record.Tables) > 0 {
+			db += " (" + strings.Join(record.Tables, ", ") + ")"
+		}
+		parts = append(parts, db)
+	}
+	if record.Files {
+		parts = append(parts, "files ("+strings.Join(record.Paths, ", ")+")")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func historyStatus(record project.DeployRecord) string {
+	switch record.Status {
+	case project.DeploySuccess:
+		return pterm.FgGreen.Sprint(record.Status)
+	case project.DeployCancelled:
+		return pterm.FgYellow.Sprint(record.Status)
+	default:
+		return pterm.FgRed.Sprint(record.Status)
+	}
+}
@@ -0,0 +1,149 @@
+package command
+
+import (
+	"context"
+
+	"github.com/local-deploy/dl/project"
+	"github.com/local-deploy/dl/utils"
+	"github.com/local-deploy/dl/utils/client"
+	"github.com/pterm/pterm"
+)
+
+// deployPlanRun Showing what the deploy would do without writing anything
+func deployPlanRun() error {
+	project.LoadEnv()
+
+	err := project.UseSource(source)
+	if err != nil {
+		return err
+	}
+
+	remote, err = getTransport(context.Background())
+	if err != nil {
+		return err
+	}
+	if sshClient, ok := remote.(*client.Client); ok {
+		defer func(client *client.Client) {
+			_ = client.Close()
+		}(sshClient)
+	}
+
+	fw, err := project.SSHClient{Transport: remote}.DetectFramework()
+	if err != nil {
+		return err
+	}
+	if fw != nil {
+		remote.Settings().FwType = fw.Name()
+	}
+
+	if !database && !files {
+		database = true
+		files = true
+	}
+
+	return printPlan(project.NewDeployPlan(remote, project.PlanOptions{Database: database, Files: files, Tables: tables,
+		Override: override, Stream: stream, Sync: syncFiles, Delete: prune}))
+}
+
+func printPlan(plan *project.DeployPlan) error {
+	yellow := pterm.NewStyle(pterm.FgLightYellow, pterm.BgDefault, pterm.Bold)
+
+	pterm.DefaultBasicText.Print("# ")
+	yellow.Println("Deploy plan")
+	pterm.Printfln("Server: %s:%s", plan.Server, plan.Catalog)
+	framework := plan.Framework
+	if len(framework) == 0 {
+		framework = "not detected"
+	}
+	pterm.Printfln("Framework: %s", framework)
+
+	if plan.DB != nil {
+		pterm.Println()
+		pterm.DefaultBasicText.Print("## ")
+		yellow.Println("Database")
+		pterm.Printfln("Server database: %s@%s:%s/%s (password: %s)",
+			plan.DB.Login, plan.DB.Host, plan.DB.Port, plan.DB.DataBase, project.MaskPassword(plan.DB.Password))
+		pterm.Printfln("Local database %q is replaced", plan.LocalDB)
+		if plan.Stream {
+			pterm.Println("The dump is imported directly from the SSH session (--stream)")
+		} else {
+			pterm.Println("The dump is created on the server and downloaded")
+		}
+
+		if len(plan.Tables) > 0 {
+			var total int64
+			data := [][]string{{"Table", "Size", "Data"}}
+			for _, table := range plan.Tables {
+				dump := "yes"
+				switch {
+				case table.Excluded:
+					dump = "excluded (schema only)"
+				case len(table.Where) > 0:
+					dump = "where " + table.Where
+					total += table.Size
+				default:
+					total += table.Size
+				}
+				data = append(data, []string{table.Name, utils.HumanSize(float64(table.Size)), dump})
+			}
+
+			err := pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+			if err != nil {
+				return err
+			}
+			pterm.Printfln("Dumped tables: %s", utils.HumanSize(float64(total)))
+		}
+	}
+
+	if len(plan.Paths) > 0 || len(plan.ConfigFiles) > 0 {
+		pterm.Println()
+		pterm.DefaultBasicText.Print("## ")
+		yellow.Println("Files")
+	}
+
+	existing := " (overwritten, local files missing on the server are kept)"
+	if len(plan.Paths) > 0 {
+		switch {
+		case plan.Sync && plan.Delete:
+			pterm.Println("New and changed files are downloaded over SFTP, local files missing on the server are deleted (--sync --delete)")
+			existing = " (synchronized, local files missing on the server are deleted)"
+		case plan.Sync:
+			pterm.Println("New and changed files are downloaded over SFTP (--sync)")
+			existing = " (synchronized, local files missing on the server are kept)"
+		case plan.Stream:
+			pterm.Println("The archive is extracted directly from the SSH session (--stream)")
+		default:
+			pterm.Println("The archive is created on the server and downloaded")
+		}
+	}
+
+	if len(plan.Paths) > 0 {
+		data := [][]string{{"Path", "Size", "Local path"}}
+		for _, path := range plan.Paths {
+			size := "unknown"
+			if path.Size >= 0 {
+				size = utils.HumanSize(float64(path.Size))
+			}
+			local := path.Local + " (created)"
+			if path.Exists {
+				local = path.Local + existing
+			}
+			data = append(data, []string{path.Path, size, local})
+		}
+
+		err := pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, file := range plan.ConfigFiles {
+		pterm.Printfln("Config updated: %s", file)
+	}
+
+	for _, warning := range plan.Warnings {
+		pterm.FgYellow.Printfln("Warning: %s", warning)
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/project"
+	"github.com/local-deploy/dl/utils/client"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var (
+	pushConfirmed bool
+	pushBackup    string
+)
+
+func deployPushCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "push",
+		Short: "Uploading local db and files to the server",
+		Long: `Uploading the local database and kernel files to the server (for example, a staging server).
+Without specifying the flag, files and the database are uploaded by default.
+
+The server database is backed up to ~/dl-backup on the server and replaced with the local dump.
+The dump is uploaded to a temporary directory outside the site root. For WordPress, the local site
+address is replaced with the address of the server. An anonymized database (ANONYMIZE, ANONYMIZE_PROFILE)
+is not uploaded. Files are extracted into CATALOG_SRV, EXCLUDED_FILES and the configuration files
+of the framework (database accesses) are not uploaded.
+
+Pushing is disabled by default: it must be allowed with ALLOW_PUSH=true,
+or SOURCE_<NAME>_ALLOW_PUSH=true for the source selected with --from.
+The server name must be typed to confirm the upload, the --yes flag skips the confirmation.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			// The errors of the push are not errors of the usage
+			cmd.SilenceUsage = true
+			return deployPushRun()
+		},
+		Example:   "dl deploy push --from stage\ndl deploy push --from stage -d\ndl deploy push --from stage -f -o upload --yes",
+		ValidArgs: []string{"--database", "--files", "--override", "--from", "--yes"},
+	}
+	cmd.Flags().BoolVarP(&database, "database", "d", false, "Upload only database to server")
+	cmd.Flags().BoolVarP(&files, "files", "f", false, "Upload only files to server")
+	cmd.Flags().StringSliceVarP(&override, "override", "o", nil, "Override uploaded files (comma separated values)")
+	cmd.Flags().StringVar(&source, "from", "", "Deploy source name (SOURCE_<NAME>_* variables)")
+	cmd.Flags().BoolVar(&pushConfirmed, "yes", false, "Upload without confirmation")
+	return cmd
+}
+
+func deployPushRun() error {
+	project.LoadEnv()
+
+	err := project.UseSource(source)
+	if err != nil {
+		return err
+	}
+
+	if !project.PushAllowed() {
+		variable := "ALLOW_PUSH"
+		if len(source) > 0 {
+			variable = "SOURCE_" + strings.ToUpper(source) + "_ALLOW_PUSH"
+		}
+		return fmt.Errorf("pushing to the server is disabled, set %s=true to allow it", variable)
+	}
+
+	if !database && !files {
+		database = true
+		files = true
+	}
+
+	if database && project.AnonymizeEnabled() {
+		return errors.New("the local database is anonymized (ANONYMIZE, ANONYMIZE_PROFILE), " +
+			"pushing it would replace the server data with masked data, use the -f flag to upload only files")
+	}
+
+	server := project.Env.GetString("SERVER")
+	if !pushConfirmed {
+		err = confirmPush(server)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	err = progress.RunWithTitle(ctx, deployPushService, os.Stdout, "Push")
+	if len(pushBackup) > 0 {
+		pterm.FgGreen.Printfln("Server database backup: %s", pushBackup)
+	}
+	// The exit code is not zero, so that scripts and CI detect the failed push
+	if err != nil {
+		return fmt.Errorf("push failed: %w", err)
+	}
+
+	fmt.Println("All done")
+
+	return nil
+}
+
+// confirmPush Requesting the server name to be typed
+func confirmPush(server string) error {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("confirmation is required, use the --yes flag in non-interactive mode")
+	}
+
+	if database {
+		pterm.FgYellow.Printf("The database on %s will be overwritten with the local one, it is backed up to ~/dl-backup on the server\n", server)
+	}
+	if files {
+		pterm.FgYellow.Printf("The files in %s on %s will be replaced with the local ones\n", project.Env.GetString("CATALOG_SRV"), server)
+	}
+	pterm.FgYellow.Print("Type the server name to continue: ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(answer) != server {
+		return errors.New("the server name does not match, push cancelled")
+	}
+
+	return nil
+}
+
+func deployPushService(ctx context.Context) error {
+	w := progress.ContextWriter(ctx)
+
+	if len(project.Env.GetString("TELEPORT")) > 0 {
+		err := errors.New("push is not supported with Teleport")
+		w.Event(progress.ErrorMessageEvent("Push", fmt.Sprint(err)))
+		return err
+	}
+
+	c, err := getClient()
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent("Failed to connect", fmt.Sprint(err)))
+		return err
+	}
+	defer func(c *client.Client) {
+		_ = c.Close()
+	}(c)
+
+	fw, err := project.SSHClient{Transport: c}.DetectFramework()
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent("Detect FW", fmt.Sprint(err)))
+		return err
+	}
+	if fw != nil {
+		c.Config.FwType = fw.Name()
+	}
+
+	if database {
+		err = project.UpDbContainer()
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent("Database", fmt.Sprint(err)))
+			return err
+		}
+
+		pushBackup, err = project.PushDB(ctx, c)
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent("Database", fmt.Sprint(err)))
+			return err
+		}
+	}
+
+	if files {
+		paths := override
+		if len(paths) == 0 && fw != nil {
+			paths = fw.Paths()
+		}
+		if len(paths) == 0 {
+			return nil
+		}
+
+		err = project.PushFiles(ctx, c, paths)
+		if err != nil {
+			w.Event(progress.ErrorMessageEvent("Files", fmt.Sprint(err)))
+			return err
+		}
+	}
+
+	return nil
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/docker/compose/v2/pkg/api"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/local-deploy/dl/project"
+	"github.com/local-deploy/dl/utils"
 	"github.com/local-deploy/dl/utils/docker"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
@@ -72,9 +74,25 @@ func runPs() error {
 		return err
 	}
 
+	printLastDeploy()
+
 	return err
 }
 
+// printLastDeploy Showing when the database was last downloaded from the server
+func printLastDeploy() {
+	record, err := project.LastDatabaseDeploy()
+	if err != nil || record == nil {
+		return
+	}
+
+	from := record.Server
+	if len(record.Source) > 0 {
+		from = record.Source
+	}
+	pterm.FgGray.Printfln("DB last pulled %s from %s", utils.FormatAgo(record.Time, time.Now()), from)
+}
+
 func getProjectContainers(ctx context.Context, cli *docker.Client, projectName string) ([]docker.ContainerSummary, error) {
 	containerFilter := filters.NewArgs(filters.Arg("label", fmt.Sprintf("%s=%s", api.ProjectLabel, projectName)))
 	containers, _ := cli.DockerCli.Client().ContainerList(ctx, container.ListOptions{Filters: containerFilter, All: true})
@@ -51,6 +51,7 @@ func Execute() {
 		execCommand(),
 		completionCommand(),
 		configCommand(),
+		dbCommand(),
 		deployCommand(),
 		docsCommand(),
 		upCommand(),
@@ -0,0 +1,221 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/utils"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Anonymization methods
+const (
+	AnonymizeEmail = "email"
+	AnonymizeHash  = "hash"
+	AnonymizeNull  = "null"
+	AnonymizeFixed = "fixed"
+)
+
+// AnonymizeProfile data masking rules applied to the local database after import
+type AnonymizeProfile struct {
+	Rules []AnonymizeRule `yaml:"rules"`
+}
+
+// AnonymizeRule masking of a table column
+type AnonymizeRule struct {
+	Table  string `yaml:"table"`
+	Column string `yaml:"column"`
+	Method string `yaml:"method"`
+	Value  string `yaml:"value,omitempty"`
+	Where  string `yaml:"where,omitempty"`
+}
+
+// laravelPassword bcrypt hash of the "password" string
+const laravelPassword = "$2y$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi"
+
+// anonymizeDB Applying the anonymization profile to the local database.
+// The profile is enabled with ANONYMIZE=true (built-in profile of the framework)
+// or ANONYMIZE_PROFILE=path/to/profile.yaml
+func anonymizeDB(ctx context.Context, fwType string) error {
+	profile, err := loadAnonymizeProfile(ctx, fwType)
+	if err != nil || profile == nil {
+		return err
+	}
+
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Database", StatusText: "Anonymize data"})
+
+	tables, err := localTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	// A missing table is an error: the deploy must not report success with the data left unmasked
+	var script []string
+	for _, rule := range profile.Rules {
+		if !tables[rule.Table] {
+			return fmt.Errorf("table %s of the anonymization profile is not found", rule.Table)
+		}
+
+		sql, err := rule.SQL(DBEngine())
+		if err != nil {
+			return err
+		}
+		script = append(script, sql)
+	}
+
+	if len(script) == 0 {
+		return nil
+	}
+
+	logrus.Infof("Run anonymization: %s", strings.Join(script, "\n"))
+
+	return execLocalSQL(ctx, strings.NewReader(strings.Join(script, "\n")), nil)
+}
+
+// AnonymizeEnabled personal data is masked after the import (ANONYMIZE or ANONYMIZE_PROFILE is set)
+func AnonymizeEnabled() bool {
+	return Env.GetBool("ANONYMIZE") || len(Env.GetString("ANONYMIZE_PROFILE")) > 0
+}
+
+func loadAnonymizeProfile(ctx context.Context, fwType string) (*AnonymizeProfile, error) {
+	path := Env.GetString("ANONYMIZE_PROFILE")
+	if len(path) > 0 {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(Env.GetString("PWD"), path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read anonymization profile: %w", err)
+		}
+
+		profile := &AnonymizeProfile{}
+		err = yaml.Unmarshal(data, profile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse anonymization profile %s: %w", path, err)
+		}
+
+		logrus.Infof("Anonymization profile is used: %s", path)
+		return profile, nil
+	}
+
+	if !Env.GetBool("ANONYMIZE") {
+		return nil, nil
+	}
+
+	fw := GetFramework(fwType)
+	if fw == nil {
+		return nil, fmt.Errorf("there is no built-in anonymization profile for the framework %q, please specify ANONYMIZE_PROFILE", fwType)
+	}
+
+	rules, err := fw.AnonymizeRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("there is no built-in anonymization profile for the framework %q, please specify ANONYMIZE_PROFILE", fwType)
+	}
+
+	logrus.Infof("Built-in anonymization profile is used: %s", fwType)
+	return &AnonymizeProfile{Rules: rules}, nil
+}
+
+// SQL update statement of the rule for the database engine
+func (r AnonymizeRule) SQL(engine string) (string, error) {
+	if len(r.Table) == 0 || len(r.Column) == 0 {
+		return "", fmt.Errorf("anonymization rule must contain table and column: %+v", r)
+	}
+
+	quote := func(s string) string {
+		return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+	}
+	hash := func(col string) string {
+		return "MD5(" + col + ")"
+	}
+	email := func(col string) string {
+		return "CONCAT(MD5(" + col + "), '@example.com')"
+	}
+	if engine == EnginePgsql {
+		quote = func(s string) string {
+			return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+		}
+		hash = func(col string) string {
+			return "md5(" + col + "::text)"
+		}
+		email = func(col string) string {
+			return "md5(" + col + "::text) || '@example.com'"
+		}
+	}
+
+	column := quote(r.Column)
+	var where []string
+
+	var value string
+	switch r.Method {
+	case AnonymizeEmail:
+		value = email(column)
+		where = append(where, column+" IS NOT NULL", column+" <> ''")
+	case AnonymizeHash:
+		value = hash(column)
+		where = append(where, column+" IS NOT NULL")
+	case AnonymizeNull:
+		value = "NULL"
+	case AnonymizeFixed:
+		value = quoteSQLString(r.Value, engine)
+	default:
+		return "", fmt.Errorf("unknown anonymization method %q for %s.%s", r.Method, r.Table, r.Column)
+	}
+
+	if len(r.Where) > 0 {
+		where = append(where, "("+r.Where+")")
+	}
+
+	sql := "UPDATE " + quote(r.Table) + " SET " + column + " = " + value
+	if len(where) > 0 {
+		sql += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	return sql + ";", nil
+}
+
+// quoteSQLString string literal for the database engine
+func quoteSQLString(s string, engine string) string {
+	if engine != EnginePgsql {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// localTables list of tables in the local database
+func localTables(ctx context.Context) (map[string]bool, error) {
+	var cmd, env []string
+	if DBEngine() == EnginePgsql {
+		cmd = []string{"psql", "--no-align", "--tuples-only", "--username=" + Env.GetString("POSTGRES_USER"), "--dbname=" + Env.GetString("POSTGRES_DB"),
+			"--command=SELECT tablename FROM pg_tables WHERE schemaname = 'public'"}
+		env = []string{"PGPASSWORD=" + Env.GetString("POSTGRES_PASSWORD")}
+	} else {
+		cmd = []string{"mysql", "--user=root", "--batch", "--skip-column-names", "--execute=SHOW TABLES", Env.GetString("MYSQL_DATABASE")}
+		env = []string{"MYSQL_PWD=" + Env.GetString("MYSQL_ROOT_PASSWORD")}
+	}
+
+	out := &bytes.Buffer{}
+	err := execLocalDB(ctx, cmd, env, nil, out)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]bool)
+	for _, table := range utils.CleanSlice(strings.Split(out.String(), "\n")) {
+		tables[strings.TrimSpace(table)] = true
+	}
+
+	return tables, nil
+}
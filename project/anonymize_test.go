@@ -0,0 +1,71 @@
+package project
+
+import "testing"
+
+func TestAnonymizeRuleSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    AnonymizeRule
+		engine  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "MySQL email",
+			rule:   AnonymizeRule{Table: "b_user", Column: "EMAIL", Method: AnonymizeEmail, Where: "ID <> 1"},
+			engine: EngineMysql,
+			want:   "UPDATE `b_user` SET `EMAIL` = CONCAT(MD5(`EMAIL`), '@example.com') WHERE `EMAIL` IS NOT NULL AND `EMAIL` <> '' AND (ID <> 1);",
+		},
+		{
+			name:   "PostgreSQL hash",
+			rule:   AnonymizeRule{Table: "users", Column: "phone", Method: AnonymizeHash},
+			engine: EnginePgsql,
+			want:   `UPDATE "users" SET "phone" = md5("phone"::text) WHERE "phone" IS NOT NULL;`,
+		},
+		{
+			name:   "MySQL null",
+			rule:   AnonymizeRule{Table: "users", Column: "remember_token", Method: AnonymizeNull},
+			engine: EngineMysql,
+			want:   "UPDATE `users` SET `remember_token` = NULL;",
+		},
+		{
+			name:   "MySQL fixed value is escaped",
+			rule:   AnonymizeRule{Table: "users", Column: "name", Method: AnonymizeFixed, Value: `O'Brien\`},
+			engine: EngineMysql,
+			want:   "UPDATE `users` SET `name` = 'O''Brien\\\\';",
+		},
+		{
+			name:    "Unknown method",
+			rule:    AnonymizeRule{Table: "users", Column: "name", Method: "shuffle"},
+			engine:  EngineMysql,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.rule.SQL(tt.engine)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SQL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SQL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWpAnonymizeRules(t *testing.T) {
+	masked := make(map[string]bool)
+	for _, rule := range wpAnonymizeRules("site1_") {
+		if rule.Table != "site1_users" && rule.Table != "site1_usermeta" {
+			t.Errorf("rule table = %s, want the site1_ prefix", rule.Table)
+		}
+		masked[rule.Column] = true
+	}
+
+	for _, column := range []string{"user_email", "user_login", "user_nicename"} {
+		if !masked[column] {
+			t.Errorf("column %s is not masked", column)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package project
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/local-deploy/dl/utils"
+	"github.com/local-deploy/dl/utils/docker"
+	"github.com/sirupsen/logrus"
+)
+
+// pgCustomMagic header of the PostgreSQL custom format dump
+const pgCustomMagic = "PGDMP"
+
+// TODO: refactoring this!
+
+// UpDbContainer Run db container before dump
+func UpDbContainer() error {
+	ctx := context.Background()
+	w := progress.ContextWriter(ctx)
+
+	w.Event(progress.StartingEvent("Starting db container"))
+
+	cli, err := dockerClient.NewClientWithOpts(dockerClient.FromEnv, dockerClient.WithAPIVersionNegotiation())
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent("Failed to connect to socket", fmt.Sprint(err)))
+		return nil
+	}
+
+	siteDb := DBContainer()
+	containerFilter := filters.NewArgs(filters.Arg("name", siteDb))
+	containerExists, err := cli.ContainerList(ctx, container.ListOptions{Filters: containerFilter})
+
+	if len(containerExists) == 0 {
+		logrus.Info("db container not running")
+		bin, option := utils.GetCompose()
+		Args := []string{bin}
+		preArgs := []string{"-p", Env.GetString("NETWORK_NAME"), "up", "-d", DBService()}
+
+		if len(option) > 0 {
+			Args = append(Args, option)
+		}
+
+		Args = append(Args, preArgs...)
+
+		logrus.Infof("Run command: %s, args: %s", bin, Args)
+		cmdCompose := &exec.Cmd{
+			Path: bin,
+			Dir:  Env.GetString("PWD"),
+			Args: Args,
+			Env:  CmdEnv(),
+		}
+
+		err = cmdCompose.Run()
+		if err != nil {
+			return err
+		}
+
+		w.Event(progress.StartedEvent("Starting db container"))
+
+		return nil
+	}
+	return nil
+}
+
+// execLocalDB Running a command in the local database container
+func execLocalDB(ctx context.Context, cmd, env []string, stdin io.Reader, stdout io.Writer) error {
+	cli, err := docker.NewClient()
+	if err != nil {
+		return err
+	}
+
+	return cli.Exec(ctx, DBContainer(), cmd, env, stdin, stdout)
+}
+
+// ImportFile Importing a dump file into the local database container.
+// Compression (gzip, zstd) and the PostgreSQL custom format are detected by the file content.
+func ImportFile(ctx context.Context, path string) error {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Database", Status: progress.Working, StatusText: "Import database"})
+
+	dump, err := os.Open(path)
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent("Database", fmt.Sprint(err)))
+		return err
+	}
+	defer dump.Close()
+
+	var size int64
+	if info, err := dump.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	r := utils.NewProgressReader(dump, size, func(read, total int64) {
+		w.Event(progress.Event{ID: "Database", StatusText: "Import database: " + utils.FormatProgress(read, total)})
+	})
+
+	logrus.Infof("Import dump: %s", path)
+	err = importLocalDB(ctx, r)
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent("Database", fmt.Sprintf("Import failed: %s", err)))
+		return err
+	}
+
+	w.Event(progress.Event{ID: "Database", Status: progress.Done, StatusText: "Imported"})
+	return nil
+}
+
+// ExportFile Exporting the local database into a file, the compression is selected by the file extension (.gz, .zst).
+// For PostgreSQL, the custom format is used for the .dump extension.
+func ExportFile(ctx context.Context, path string) error {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Database", Status: progress.Working, StatusText: "Export database"})
+
+	err := exportLocalDB(ctx, path, nil)
+	if err != nil {
+		_ = os.Remove(path)
+		w.Event(progress.ErrorMessageEvent("Database", fmt.Sprintf("Export failed: %s", err)))
+		return err
+	}
+
+	w.Event(progress.Event{ID: "Database", Status: progress.Done, StatusText: "Exported"})
+	return nil
+}
+
+// exportLocalDB Exporting the local database into the file, the strings of the MySQL dump are replaced with sr if it is set
+func exportLocalDB(ctx context.Context, path string, sr *SearchReplace) error {
+	w := progress.ContextWriter(ctx)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	out, err := utils.Compress(file, path)
+	if err != nil {
+		return err
+	}
+
+	var cmd, env []string
+	if DBEngine() == EnginePgsql {
+		cmd = []string{"pg_dump", "--no-owner", "--no-acl", "--username=" + Env.GetString("POSTGRES_USER"), Env.GetString("POSTGRES_DB")}
+		if strings.HasSuffix(path, ".dump") {
+			cmd = append(cmd, "--format=custom")
+		}
+		env = []string{"PGPASSWORD=" + Env.GetString("POSTGRES_PASSWORD")}
+	} else {
+		cmd = []string{"mysqldump", "--user=root", "--single-transaction", "--no-tablespaces", "--routines", Env.GetString("MYSQL_DATABASE")}
+		env = []string{"MYSQL_PWD=" + Env.GetString("MYSQL_ROOT_PASSWORD")}
+	}
+
+	logrus.Infof("Export dump: %s", path)
+	pw := utils.NewProgressWriter(out, func(written int64) {
+		w.Event(progress.Event{ID: "Database", StatusText: "Export database: " + utils.FormatProgress(written, 0)})
+	})
+	if sr == nil {
+		err = execLocalDB(ctx, cmd, env, nil, pw)
+	} else {
+		pr, dump := io.Pipe()
+		go func() {
+			_ = dump.CloseWithError(execLocalDB(ctx, cmd, env, nil, dump))
+		}()
+		err = sr.Copy(pw, pr)
+		_ = pr.CloseWithError(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// importLocalDB Importing a dump from the reader into the local database container, compressed dumps are unpacked
+func importLocalDB(ctx context.Context, dump io.Reader) error {
+	r, err := utils.Decompress(dump)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if DBEngine() == EnginePgsql {
+		return importPgsql(ctx, r)
+	}
+
+	return execLocalSQL(ctx, r, nil)
+}
+
+// execLocalSQL Executing SQL statements from the reader in the local database
+func execLocalSQL(ctx context.Context, sql io.Reader, stdout io.Writer) error {
+	if DBEngine() == EnginePgsql {
+		pgDB := Env.GetString("POSTGRES_DB")
+		pgUser := Env.GetString("POSTGRES_USER")
+		pgPassword := Env.GetString("POSTGRES_PASSWORD")
+
+		cmd := []string{"psql", "--quiet", "--set=ON_ERROR_STOP=1", "--username=" + pgUser, "--dbname=" + pgDB}
+		return execLocalDB(ctx, cmd, []string{"PGPASSWORD=" + pgPassword}, sql, stdout)
+	}
+
+	mysqlDB := Env.GetString("MYSQL_DATABASE")
+	mysqlRootPassword := Env.GetString("MYSQL_ROOT_PASSWORD")
+
+	return execLocalDB(ctx, []string{"mysql", "--user=root", mysqlDB}, []string{"MYSQL_PWD=" + mysqlRootPassword}, sql, stdout)
+}
+
+// importPgsql Importing a dump into a local PostgreSQL container.
+// Custom format dumps are restored with pg_restore, plain SQL dumps are executed with psql, which stops at the first error.
+func importPgsql(ctx context.Context, dump io.Reader) error {
+	pgDB := Env.GetString("POSTGRES_DB")
+	pgUser := Env.GetString("POSTGRES_USER")
+	pgPassword := Env.GetString("POSTGRES_PASSWORD")
+
+	br := bufio.NewReader(dump)
+	magic, _ := br.Peek(len(pgCustomMagic))
+
+	cmd := []string{"psql", "--quiet", "--set=ON_ERROR_STOP=1", "--username=" + pgUser, "--dbname=" + pgDB}
+	if string(magic) == pgCustomMagic {
+		cmd = []string{"pg_restore", "--no-owner", "--no-acl", "--clean", "--if-exists", "--username=" + pgUser, "--dbname=" + pgDB}
+	}
+
+	return execLocalDB(ctx, cmd, []string{"PGPASSWORD=" + pgPassword}, br, nil)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os/exec"
+	"io"
+	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -17,66 +19,124 @@ import (
 
 var remotePhpPath string
 
-// DumpDB Database import from server
-func DumpDB(ctx context.Context, client *client.Client, tables []string) {
-	var err error
+// DumpOptions settings of downloading the database from the server
+type DumpOptions struct {
+	// Tables only these tables are dumped
+	Tables []string
+	// Stream the dump is imported on the fly from the SSH session
+	Stream bool
+	// Cache the dump is saved in the dump cache
+	Cache bool
+	// SpaceChecked the free space was checked for the dump and the files archive together
+	SpaceChecked bool
+}
 
-	c := &SSHClient{client}
+// DumpDB Database import from server.
+// In the stream mode the dump is imported directly from the remote command output without creating files.
+// The dump is deleted on the server and locally when the import is finished, failed or cancelled.
+// Returns the size of the transferred dump.
+func DumpDB(ctx context.Context, t client.Transport, opts DumpOptions) (int64, error) {
+	c := &SSHClient{t}
 	w := progress.ContextWriter(ctx)
 	w.Event(progress.Event{ID: "Database", Status: progress.Working})
 
-	db, err := c.getMysqlSettings()
+	// The production data is masked only in the local database, the raw dump is not cached
+	if opts.Cache && AnonymizeEnabled() {
+		logrus.Info("Anonymization is enabled, the dump is not saved in the cache")
+		opts.Cache = false
+	}
+
+	size, err := c.dumpDB(ctx, opts)
 	if err != nil {
 		w.Event(progress.ErrorMessageEvent("Database", fmt.Sprint(err)))
-		return
+		return 0, err
 	}
 
-	if len(db.Port) == 0 {
-		logrus.Info("Port not set, standard port 3306 is used")
-		db.Port = "3306"
+	w.Event(progress.Event{ID: "Database", Status: progress.Done})
+	return size, nil
+}
+
+func (c SSHClient) dumpDB(ctx context.Context, opts DumpOptions) (int64, error) {
+	db, err := c.getMysqlSettings()
+	if err != nil {
+		return 0, err
 	}
 
-	if len(tables) > 0 {
-		dumpTables := strings.Join(tables, " ")
-		err = c.mysqlDumpTables(ctx, db, dumpTables)
-	} else {
-		err = c.mysqlDump(ctx, db)
+	db.setDefaultPort()
+
+	if opts.Stream {
+		size, err := c.streamDump(ctx, db, opts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to stream database dump: %w", err)
+		}
+		return size, nil
 	}
 
+	serverPath := filepath.Join(c.Settings().Catalog, DumpFileName())
+	localPath := filepath.Join(Env.GetString("PWD"), DumpFileName())
+	defer c.removeArtifacts(serverPath, localPath)
+
+	err = c.createDump(ctx, db, opts)
 	if err != nil {
-		w.Event(progress.ErrorMessageEvent("Database", fmt.Sprintf("Failed to create database dump: %s", err)))
-		return
+		return 0, fmt.Errorf("failed to create database dump: %w", err)
 	}
 
 	err = c.downloadDump(ctx)
 	if err != nil {
-		w.Event(progress.ErrorMessageEvent("Database", fmt.Sprintf("Failed to download dump: %s", err)))
-		return
+		return 0, fmt.Errorf("failed to download dump: %w", err)
 	}
 
-	err = c.ImportDB(ctx)
+	if opts.Cache {
+		// The cache is optional, the deploy continues without it
+		err = cacheFile(localPath, opts.Tables, db, c.Settings().FwType)
+		if err != nil {
+			logrus.Infof("Failed to save dump in the cache: %s", err)
+		}
+	}
+
+	size, err := c.ImportDB(ctx)
 	if err != nil {
-		w.Event(progress.ErrorMessageEvent("Database", fmt.Sprintf("Access error: %s", err)))
+		return 0, fmt.Errorf("failed to import dump: %w", err)
+	}
+
+	return size, nil
+}
+
+// setDefaultPort standard port of the database engine if the port is not set
+func (d *DBSettings) setDefaultPort() {
+	if len(d.Port) > 0 {
 		return
 	}
 
-	w.Event(progress.Event{ID: "Database", Status: progress.Done})
+	if DBEngine() == EnginePgsql {
+		logrus.Info("Port not set, standard port 5432 is used")
+		d.Port = "5432"
+	} else {
+		logrus.Info("Port not set, standard port 3306 is used")
+		d.Port = "3306"
+	}
 }
 
 func (c SSHClient) getMysqlSettings() (*DBSettings, error) {
 	var err error
 	var db *DBSettings
 
-	mysqlDataBase := Env.GetString("MYSQL_DATABASE_SRV")
-	mysqlLogin := Env.GetString("MYSQL_LOGIN_SRV")
-	mysqlPassword := Env.GetString("MYSQL_PASSWORD_SRV")
+	// PostgreSQL accesses are set with the POSTGRES_*_SRV variables
+	prefix := "MYSQL"
+	if DBEngine() == EnginePgsql {
+		prefix = "POSTGRES"
+	}
+
+	mysqlDataBase := Env.GetString(prefix + "_DATABASE_SRV")
+	mysqlLogin := Env.GetString(prefix + "_LOGIN_SRV")
+	mysqlPassword := Env.GetString(prefix + "_PASSWORD_SRV")
 	if len(mysqlDataBase) > 0 && len(mysqlLogin) > 0 && len(mysqlPassword) > 0 {
 		logrus.Info("Manual database access settings are used")
 		excludedTables := strings.Split(strings.TrimSpace(Env.GetString("EXCLUDED_TABLES")), ",")
 
 		db = &DBSettings{
-			Host:           Env.GetString("MYSQL_HOST_SRV"),
-			Port:           Env.GetString("MYSQL_PORT_SRV"),
+			Host:           Env.GetString(prefix + "_HOST_SRV"),
+			Port:           Env.GetString(prefix + "_PORT_SRV"),
 			DataBase:       mysqlDataBase,
 			Login:          mysqlLogin,
 			Password:       mysqlPassword,
@@ -86,20 +146,21 @@ func (c SSHClient) getMysqlSettings() (*DBSettings, error) {
 		c.checkPhpAvailable()
 
 		logrus.Info("Attempt to access database")
-		switch c.Config.FwType {
-		case "bitrix":
-			db, err = c.accessBitrixDB()
-		case "laravel":
-			db, err = c.accessLaravelDB()
-		case "wordpress":
-			db, err = c.accessWpDB()
-		default:
-			err = errors.New("failed determine the Framework, please specify accesses manually https://v7m.ru/s/mvavg")
+		fw := GetFramework(c.Settings().FwType)
+		if fw == nil {
+			return nil, errors.New("access error: failed determine the Framework, please specify accesses manually https://v7m.ru/s/mvavg")
 		}
 
+		db, err = fw.DBSettings(c)
 		if err != nil {
 			return nil, fmt.Errorf("access error: %w", err)
 		}
+		db.ExcludedTables = strings.Split(strings.TrimSpace(Env.GetString("EXCLUDED_TABLES")), ",")
+	}
+
+	db.FilteredTables, err = TableFilters()
+	if err != nil {
+		return nil, err
 	}
 
 	return db, nil
@@ -108,7 +169,7 @@ func (c SSHClient) getMysqlSettings() (*DBSettings, error) {
 // checkPhpAvailable It possible that PHP not installed on the server in the host system. For example, through docker.
 func (c SSHClient) checkPhpAvailable() {
 	logrus.Info("Check if PHP available")
-	phpCmd := strings.Join([]string{"cd", c.Config.Catalog, "&&", "which php"}, " ")
+	phpCmd := strings.Join([]string{"cd", c.Settings().Catalog, "&&", "which php"}, " ")
 	logrus.Infof("Run command: %s", phpCmd)
 	binary, err := c.Run(phpCmd)
 	if err == nil {
@@ -119,169 +180,183 @@ func (c SSHClient) checkPhpAvailable() {
 	logrus.Info("PHP not available")
 }
 
-// accessBitrixDB Attempt to determine database accesses
-func (c SSHClient) accessBitrixDB() (*DBSettings, error) {
-	var catCmd string
-	if len(remotePhpPath) > 0 {
-		// A more precise way to define variables
-		catCmd = strings.Join([]string{"cd", c.Config.Catalog, "&&",
-			`$(which php) -r '$settings = include "bitrix/.settings.php"; echo $settings["connections"]["value"]["default"]["host"]."\n";
-echo $settings["connections"]["value"]["default"]["database"]."\n";
-echo $settings["connections"]["value"]["default"]["login"]."\n";
-echo $settings["connections"]["value"]["default"]["password"]."\n";'`,
-		}, " ")
-	} else {
-		// Defining variables with grep
-		catCmd = strings.Join([]string{"cd", c.Config.Catalog, "&&",
-			`cat bitrix/.settings.php | grep "'host' *\=>" | awk '{print $3}' | sed -e 's/^.\{1\}//' | sed 's/^\(.*\).$/\1/' | sed 's/^\(.*\).$/\1/'`, "&&",
-			`cat bitrix/.settings.php | grep "'database' *\=>" | awk '{print $3}' | sed -e 's/^.\{1\}//' | sed 's/^\(.*\).$/\1/' | sed 's/^\(.*\).$/\1/'`, "&&",
-			`cat bitrix/.settings.php | grep "'login' *\=>" | awk '{print $3}' | sed -e 's/^.\{1\}//' | sed 's/^\(.*\).$/\1/' | sed 's/^\(.*\).$/\1/'`, "&&",
-			`cat bitrix/.settings.php | grep "'password' *\=>" | awk '{print $3}' | sed -e 's/^.\{1\}//' | sed 's/^\(.*\).$/\1/' | sed 's/^\(.*\).$/\1/'`,
-		}, " ")
-	}
+// createDump Create database dump file on the server
+func (c SSHClient) createDump(ctx context.Context, db *DBSettings, opts DumpOptions) error {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Database", StatusText: "Create database dump"})
 
-	logrus.Infof("Run command: %s", catCmd)
-	cat, err := c.Run(catCmd)
+	dumpCmd, err := c.dumpCommand(db, opts.Tables)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	dbArray := utils.CleanSlice(strings.Split(strings.TrimSpace(string(cat)), "\n"))
-	logrus.Infof("Received variables: %s", dbArray)
-	if len(dbArray) != 4 {
-		return nil, errors.New("failed to define DB variables, please specify accesses manually")
+	if !opts.SpaceChecked {
+		err = c.checkRemoteSpace("database dump", c.estimateDumpSize(db, opts.Tables))
+		if err != nil {
+			return err
+		}
 	}
 
-	excludedTables := strings.Split(strings.TrimSpace(Env.GetString("EXCLUDED_TABLES")), ",")
-
-	return &DBSettings{
-		Host:           dbArray[0],
-		DataBase:       dbArray[1],
-		Login:          dbArray[2],
-		Password:       dbArray[3],
-		ExcludedTables: excludedTables,
-	}, err
+	dumpCmd = dumpCmd + " > " + c.Settings().Catalog + "/" + DumpFileName()
+	logrus.Infof("Run command: %s", dumpCmd)
+	return c.runContext(ctx, dumpCmd)
 }
 
-// accessWpDB Attempt to determine database accesses
-func (c SSHClient) accessWpDB() (*DBSettings, error) {
-	catCmd := strings.Join([]string{"cd", c.Config.Catalog, "&&",
-		`$(which php) -r 'error_reporting(0); define("SHORTINIT",true); $settings = include "wp-config.php"; echo DB_HOST."\n"; echo DB_NAME."\n"; echo DB_USER."\n"; echo DB_PASSWORD."\n";'`,
-	}, " ")
-	logrus.Infof("Run command: %s", catCmd)
-	cat, err := c.Run(catCmd)
+// streamDump Create database dump and import it from the SSH session output, returns the size of the received dump.
+// With the cache enabled, the received dump is also written to the cache.
+func (c SSHClient) streamDump(ctx context.Context, db *DBSettings, opts DumpOptions) (int64, error) {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Database", StatusText: "Stream database dump"})
+
+	dumpCmd, err := c.dumpCommand(db, opts.Tables)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	dbArray := strings.Split(strings.TrimSpace(string(cat)), "\n")
-	logrus.Infof("Received variables: %s", dbArray)
-	if len(dbArray) != 4 {
-		return nil, errors.New("failed to define DB variables, please specify accesses manually")
+	logrus.Infof("Run command: %s", dumpCmd)
+	stream, err := c.Stream(dumpCmd)
+	if err != nil {
+		return 0, err
+	}
+	defer func(stream client.RemoteStream) {
+		_ = stream.Close()
+	}(stream)
+	stop := context.AfterFunc(ctx, func() {
+		_ = stream.Close()
+	})
+	defer stop()
+
+	var (
+		dump  io.Reader = stream
+		cache *cacheWriter
+	)
+	if opts.Cache {
+		cache, err = newCacheWriter(opts.Tables, db, c.Settings().FwType)
+		if err != nil {
+			logrus.Infof("Failed to save dump in the cache: %s", err)
+		} else {
+			defer cache.Discard()
+			dump = io.TeeReader(stream, cache)
+		}
 	}
 
-	excludedTables := strings.Split(strings.TrimSpace(Env.GetString("EXCLUDED_TABLES")), ",")
+	size, err := importDump(ctx, c.Settings().FwType, dump, 0)
+	if err != nil {
+		return 0, err
+	}
 
-	return &DBSettings{
-		Host:           dbArray[0],
-		DataBase:       dbArray[1],
-		Login:          dbArray[2],
-		Password:       dbArray[3],
-		ExcludedTables: excludedTables,
-	}, err
-}
+	if cache != nil {
+		// The rest of the stream is read so that the cached dump is complete
+		_, err = io.Copy(io.Discard, dump)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = stream.Wait()
+	if err != nil {
+		return 0, err
+	}
+
+	if cache != nil {
+		if err = cache.Commit(); err != nil {
+			logrus.Infof("Failed to save dump in the cache: %s", err)
+		}
+	}
 
-func (c SSHClient) accessLaravelDB() (*DBSettings, error) {
-	catCmd := strings.Join([]string{"cd", c.Config.Catalog, "&&", "export $(grep -v '^#' .env | xargs)", "&&",
-		`echo $DB_HOST`, "&&",
-		`echo $DB_DATABASE`, "&&",
-		`echo $DB_USERNAME`, "&&",
-		`echo $DB_PASSWORD`,
-	}, " ")
-	logrus.Infof("Run command: %s", catCmd)
-	cat, err := c.Run(catCmd)
-
-	dbArray := strings.Split(strings.TrimSpace(string(cat)), "\n")
-	logrus.Infof("Received variables: %s", dbArray)
-	if len(dbArray) != 4 {
-		return nil, errors.New("failed to define DB variables, please specify accesses manually")
-	}
-
-	excludedTables := strings.Split(strings.TrimSpace(Env.GetString("EXCLUDED_TABLES")), ",")
-
-	return &DBSettings{
-		Host:           dbArray[0],
-		DataBase:       dbArray[1],
-		Login:          dbArray[2],
-		Password:       dbArray[3],
-		ExcludedTables: excludedTables,
-	}, err
+	return size, nil
 }
 
-// mysqlDump Create database dump
-func (c SSHClient) mysqlDump(ctx context.Context, db *DBSettings) error {
-	w := progress.ContextWriter(ctx)
-	w.Event(progress.Event{ID: "Database", StatusText: "Create database dump"})
+// dumpCommand Command that writes the database dump to stdout
+func (c SSHClient) dumpCommand(db *DBSettings, tables []string) (string, error) {
+	if DBEngine() == EnginePgsql {
+		if c.checkPgDumpAvailable() != nil {
+			return "", errors.New("pg_dump not installed, database dump not possible")
+		}
+		return c.pgDumpCommand(db, tables), nil
+	}
 
-	dump := c.checkMySQLDumpAvailable()
-	if dump != nil {
-		return errors.New("mysqldump not installed, database dump not possible")
+	if c.checkMySQLDumpAvailable() != nil {
+		return "", errors.New("mysqldump not installed, database dump not possible")
 	}
 
+	if len(tables) > 0 {
+		return c.mysqlDumpTablesCommand(db, tables), nil
+	}
+
+	return c.mysqlDumpCommand(db), nil
+}
+
+// mysqlDumpCommand Full database dump: schema of all tables and data without excluded tables,
+// the data of the filtered tables is dumped separately with their conditions
+func (c SSHClient) mysqlDumpCommand(db *DBSettings) string {
+	filters := db.dumpFilters(nil)
 	ignoredTablesString := db.FormatIgnoredTables()
+	for _, table := range filteredTableNames(filters) {
+		ignoredTablesString += " --ignore-table=" + db.DataBase + "." + table
+	}
 	dumpTablesParams := db.DumpTablesParams()
 	dumpDataParams := db.DumpDataParams()
-	dumpCmd := strings.Join([]string{"cd", c.Config.Catalog, "&&",
+
+	return strings.Join([]string{"cd", c.Settings().Catalog, "&&", pipefail(
+		"(",
 		"mysqldump",
 		dumpTablesParams,
 		db.DataBase,
-		"|",
-		"gzip > " + c.Config.Catalog + "/production.sql.gz",
 		"&&",
 		"mysqldump",
 		dumpDataParams,
 		ignoredTablesString,
 		db.DataBase,
+		mysqlFilteredDump(dumpDataParams, db.DataBase, filters),
+		")",
 		"|",
-		"gzip >> " + c.Config.Catalog + "/production.sql.gz",
-	}, " ")
-	logrus.Infof("Run command: %s", dumpCmd)
-	_, err := c.Run(dumpCmd)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
+		"gzip",
+	)}, " ")
 }
 
-// mysqlDumpTables Create only tables dump
-func (c SSHClient) mysqlDumpTables(ctx context.Context, db *DBSettings, dumpTables string) error {
-	w := progress.ContextWriter(ctx)
-	w.Event(progress.Event{ID: "Database", StatusText: "Creating database dump"})
+// mysqlDumpTablesCommand Only tables dump, the filtered tables are dumped with their conditions
+func (c SSHClient) mysqlDumpTablesCommand(db *DBSettings, tables []string) string {
+	filters := db.dumpFilters(tables)
+	dumpDataParams := db.DumpDataTablesParams()
 
-	dump := c.checkMySQLDumpAvailable()
-	if dump != nil {
-		return errors.New("mysqldump not installed, database dump not possible")
+	var dumpTables []string
+	for _, table := range tables {
+		if !slices.Contains(filteredTableNames(filters), table) {
+			dumpTables = append(dumpTables, table)
+		}
 	}
 
-	dumpDataParams := db.DumpDataTablesParams()
-	dumpCmd := strings.Join([]string{"cd", c.Config.Catalog, "&&",
-		"mysqldump",
-		dumpDataParams,
-		db.DataBase,
-		dumpTables,
+	dumpCmd := []string{"("}
+	if len(dumpTables) > 0 {
+		dumpCmd = append(dumpCmd, "mysqldump", dumpDataParams, db.DataBase, strings.Join(dumpTables, " "))
+	} else {
+		dumpCmd = append(dumpCmd, "true")
+	}
+	dumpCmd = append(dumpCmd,
+		mysqlFilteredDump(dumpDataParams, db.DataBase, filters),
+		")",
 		"|",
-		"gzip > " + c.Config.Catalog + "/production.sql.gz",
-	}, " ")
-	logrus.Infof("Run command: %s", dumpCmd)
-	_, err := c.Run(dumpCmd)
+		"gzip",
+	)
 
-	if err != nil {
-		return err
+	return strings.Join([]string{"cd", c.Settings().Catalog, "&&", pipefail(dumpCmd...)}, " ")
+}
+
+// pipefail Running the pipeline in bash with the pipefail option: the exit status of "mysqldump | gzip"
+// is the status of the failed mysqldump instead of gzip, so a truncated dump is not imported
+func pipefail(pipeline ...string) string {
+	return "bash -o pipefail -c " + utils.ShellQuote(strings.Join(pipeline, " "))
+}
+
+// mysqlFilteredDump commands dumping the rows of the filtered tables, they are appended to the previous command
+func mysqlFilteredDump(params, database string, filters []TableFilter) string {
+	var commands []string
+	for _, filter := range filters {
+		commands = append(commands, "&&", "mysqldump", params, "--where="+utils.ShellQuote(filter.Condition()), database, filter.Table)
 	}
 
-	return nil
+	return strings.Join(commands, " ")
 }
 
 // DumpDataTablesParams options for only tables dump
@@ -307,7 +382,7 @@ func (d DBSettings) DumpDataTablesParams() string {
 
 func (c SSHClient) checkMySQLDumpAvailable() error {
 	logrus.Info("Check if mysqldump available")
-	dumpCmd := strings.Join([]string{"cd", c.Config.Catalog, "&&", "which mysqldump"}, " ")
+	dumpCmd := strings.Join([]string{"cd", c.Settings().Catalog, "&&", "which mysqldump"}, " ")
 	logrus.Infof("Run command: %s", dumpCmd)
 	_, err := c.Run(dumpCmd)
 	if err != nil {
@@ -375,83 +450,95 @@ func (d DBSettings) FormatIgnoredTables() string {
 	return strings.Join(ignoredTables, " ")
 }
 
-// downloadDump Downloading a dump and deleting an archive from the server
+// DumpFileName Name of the dump file on the server and locally
+func DumpFileName() string {
+	if DBEngine() == EnginePgsql {
+		return "production.dump"
+	}
+
+	return "production.sql.gz"
+}
+
+// downloadDump Downloading a dump from the server
 func (c SSHClient) downloadDump(ctx context.Context) error {
 	w := progress.ContextWriter(ctx)
 	w.Event(progress.Event{ID: "Database", StatusText: "Download database dump"})
 
-	serverPath := filepath.Join(c.Config.Catalog, "production.sql.gz")
-	localPath := filepath.Join(Env.GetString("PWD"), "production.sql.gz")
+	serverPath := filepath.Join(c.Settings().Catalog, DumpFileName())
+	localPath := filepath.Join(Env.GetString("PWD"), DumpFileName())
 
 	logrus.Infof("Download dump: %s", serverPath)
-	err := c.Download(ctx, serverPath, localPath)
+	return c.download(ctx, "Database", serverPath, localPath)
+}
+
+// ImportDB Importing a downloaded dump into a local container, returns the size of the dump
+func (c SSHClient) ImportDB(ctx context.Context) (int64, error) {
+	localPath := filepath.Join(Env.GetString("PWD"), DumpFileName())
 
+	dump, err := os.Open(localPath)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	var size int64
+	if info, err := dump.Stat(); err == nil {
+		size = info.Size()
 	}
 
-	err = c.CleanRemote(serverPath)
+	_, err = importDump(ctx, c.Settings().FwType, dump, size)
+	_ = dump.Close()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return err
+	logrus.Infof("Delete dump: %s", localPath)
+	return size, os.Remove(localPath)
 }
 
-// ImportDB Importing a database into a local container
-func (c SSHClient) ImportDB(ctx context.Context) error {
+// importDump Importing a dump from the reader into a local container, size is 0 if unknown.
+// The post-import steps of the framework are applied. Returns the number of bytes read.
+func importDump(ctx context.Context, fwType string, dump io.Reader, size int64) (int64, error) {
 	w := progress.ContextWriter(ctx)
 	w.Event(progress.Event{ID: "Database", StatusText: "Import database"})
 
-	docker, err := exec.LookPath("docker")
-	if err != nil {
-		return err
-	}
-	gunzip, err := exec.LookPath("gunzip")
+	r := utils.NewProgressReader(dump, size, func(read, total int64) {
+		w.Event(progress.Event{ID: "Database", StatusText: "Import database: " + utils.FormatProgress(read, total)})
+	})
+
+	err := importLocalDB(ctx, r)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	// TODO: переписать на sdk
-	localPath := filepath.Join(Env.GetString("PWD"), "production.sql.gz")
-	site := Env.GetString("HOST_NAME")
-	siteDB := site + "_db"
-
-	mysqlDB := Env.GetString("MYSQL_DATABASE")
-	mysqlUser := Env.GetString("MYSQL_USER")
-	mysqlPassword := Env.GetString("MYSQL_PASSWORD")
-	mysqlRootPassword := Env.GetString("MYSQL_ROOT_PASSWORD")
+	if fw := GetFramework(fwType); fw != nil {
+		err = fw.PostImport(ctx)
+		if err != nil {
+			if AnonymizeEnabled() {
+				return 0, discardImport(ctx, err)
+			}
+			return 0, err
+		}
+	}
 
-	commandImport := gunzip + " < " + "production.sql.gz" + " | " + docker + " exec -i " + siteDB + " /usr/bin/mysql --user=root --password=" + mysqlRootPassword + " " + mysqlDB + ""
-	logrus.Infof("Run command: %s", commandImport)
-	outImport, err := exec.Command("bash", "-c", commandImport).CombinedOutput() //nolint:gosec
+	err = anonymizeDB(ctx, fwType)
 	if err != nil {
-		return errors.New(string(outImport))
+		return 0, discardImport(ctx, err)
 	}
 
-	if c.Config.FwType == "bitrix" {
-		local := Env.GetString("LOCAL_DOMAIN")
-		nip := Env.GetString("NIP_DOMAIN")
-
-		strSQL := `"UPDATE b_option SET VALUE = 'Y' WHERE MODULE_ID = 'main' AND NAME = 'update_devsrv';
-UPDATE b_lang SET SERVER_NAME='` + site + `.localhost' WHERE LID='s1';
-UPDATE b_lang SET b_lang.DOC_ROOT='' WHERE 1=(SELECT DOC_ROOT FROM (SELECT COUNT(LID) FROM b_lang) as cnt);
-INSERT IGNORE INTO b_lang_domain VALUES ('s1', '` + local + `');
-INSERT IGNORE INTO b_lang_domain VALUES ('s1', '` + nip + `');"`
+	return r.BytesRead(), nil
+}
 
-		commandUpdate := "echo " + strSQL + " | " + docker + " exec -i " + siteDB + " /usr/bin/mysql --user=" + mysqlUser + " --password=" + mysqlPassword + " --host=db " + mysqlDB + ""
-		logrus.Infof("Run command: %s", commandUpdate)
-		outUpdate, err := exec.Command("bash", "-c", commandUpdate).CombinedOutput() //nolint:gosec
-		if err != nil {
-			return errors.New(string(outUpdate))
-		}
-	}
+// discardImport Deleting the imported data that is not anonymized: the production data
+// must not stay in the local database. The data is deleted even if the deploy is cancelled.
+func discardImport(ctx context.Context, cause error) error {
+	logrus.Errorf("Anonymization failed, delete the imported data: %s", cause)
 
-	logrus.Infof("Delete dump: %s", localPath)
-	err = exec.Command("rm", localPath).Run()
+	err := clearLocalDB(context.WithoutCancel(ctx))
 	if err != nil {
-		return err
+		logrus.Errorf("Failed to delete the imported data: %s", err)
+		return fmt.Errorf("anonymization failed: %w. WARNING: the local database contains NOT anonymized data "+
+			"and could not be cleared (%s), run the deploy again or clear the database", cause, err)
 	}
 
-	return nil
+	return fmt.Errorf("anonymization failed, the imported data is deleted, run the deploy again: %w", cause)
 }
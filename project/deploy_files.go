@@ -2,97 +2,198 @@ package project
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"reflect"
 	"strings"
 
 	"github.com/docker/compose/v2/pkg/progress"
 	"github.com/local-deploy/dl/utils"
 	"github.com/local-deploy/dl/utils/client"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 )
 
-// CopyFiles Copying files from the server
-func CopyFiles(ctx context.Context, client *client.Client, override []string) {
-	var (
-		err  error
-		path string
-	)
+// extractDirPattern temporary directory in BACKEND_ROOT for extracting the archive
+const extractDirPattern = ".dl-extract-*"
+
+// FilesOptions settings of downloading files from the server
+type FilesOptions struct {
+	// Override paths instead of the framework defaults
+	Override []string
+	// Sync only changed files are downloaded over SFTP
+	Sync bool
+	// Delete local files missing on the server are deleted (with Sync)
+	Delete bool
+	// Stream the archive is extracted on the fly from the SSH session
+	Stream bool
+	// SpaceChecked the free space was checked for the archive and the database dump together
+	SpaceChecked bool
+}
 
-	c := &SSHClient{client}
+// CopyFiles Copying files from the server, returns the size of the transferred data.
+// The archive is deleted on the server and locally when the deploy is finished, failed or cancelled.
+func CopyFiles(ctx context.Context, t client.Transport, opts FilesOptions) (int64, error) {
+	c := &SSHClient{t}
 
 	w := progress.ContextWriter(ctx)
 	w.Event(progress.Event{ID: "Files", Status: progress.Working})
 
-	switch client.Config.FwType {
-	case "bitrix":
-		path = "bitrix"
-	case "wordpress":
-		path = "wp-admin wp-includes"
+	size, err := c.copyFiles(ctx, opts)
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent("Files", fmt.Sprint(err)))
+		return 0, err
+	}
+
+	w.Event(progress.Event{ID: "Files", Status: progress.Done})
+	return size, nil
+}
+
+func (c SSHClient) copyFiles(ctx context.Context, opts FilesOptions) (int64, error) {
+	fw := GetFramework(c.Settings().FwType)
+	if fw == nil {
+		return 0, nil
+	}
+	path := c.filesPath(opts.Override)
+
+	var (
+		err  error
+		size int64
+	)
+
+	// Some frameworks (Laravel) have no files to download, only the local config is updated
+	switch {
+	case len(path) == 0:
+	case opts.Sync:
+		// Incremental sync requires SFTP
+		sshClient, ok := c.Transport.(*client.Client)
+		if !ok {
+			return 0, errors.New("the --sync flag is supported only over SSH")
+		}
+		logrus.Infof("Sync path with server: %s", path)
+		size, err = SyncFiles(ctx, sshClient, strings.Split(path, " "), opts.Delete)
+	case opts.Stream:
+		logrus.Infof("Stream path from server: %s", path)
+		size, err = c.streamFiles(ctx, path)
 	default:
-		return
+		logrus.Infof("Download path from server: %s", path)
+		size, err = c.downloadFiles(ctx, path, !opts.SpaceChecked)
+	}
+	if err != nil {
+		return 0, err
 	}
 
+	return size, fw.UpdateConfig()
+}
+
+// filesPath downloaded paths separated by spaces: the override or the paths of the framework
+func (c SSHClient) filesPath(override []string) string {
 	if len(override) > 0 {
-		path = strings.Join(override, " ")
+		return strings.Join(override, " ")
 	}
 
-	logrus.Infof("Download path from server: %s", path)
-	err = c.packFiles(ctx, path)
+	fw := GetFramework(c.Settings().FwType)
+	if fw == nil {
+		return ""
+	}
+
+	return strings.Join(fw.Paths(), " ")
+}
+
+// downloadFiles Creating the archive on the server, downloading and extracting it, returns the archive size
+func (c SSHClient) downloadFiles(ctx context.Context, path string, checkSpace bool) (int64, error) {
+	serverPath := filepath.Join(c.Settings().Catalog, "production.tar.gz")
+	localPath := filepath.Join(Env.GetString("PWD"), "production.tar.gz")
+	defer c.removeArtifacts(serverPath, localPath)
 
+	err := c.packFiles(ctx, path, checkSpace)
 	if err != nil {
-		fmt.Printf("Error: %s \n", err)
-		os.Exit(1)
+		return 0, err
 	}
 
 	err = c.downloadArchive(ctx)
 	if err != nil {
-		w.Event(progress.ErrorMessageEvent("Files", fmt.Sprint(err)))
-		return
+		return 0, err
 	}
 
-	err = ExtractArchive(ctx, path)
+	info, err := os.Stat(localPath)
 	if err != nil {
-		w.Event(progress.ErrorMessageEvent("Files", fmt.Sprint(err)))
-		return
+		return 0, err
 	}
 
-	var a CallMethod
-	reflect.
-		ValueOf(&a).
-		MethodByName(cases.Title(language.Und, cases.NoLower).String(client.Config.FwType + "Access")).
-		Call([]reflect.Value{})
-
-	w.Event(progress.Event{ID: "Files", Status: progress.Done})
+	return info.Size(), ExtractArchive(ctx, path)
 }
 
 // packFiles Add files to archive
-func (c SSHClient) packFiles(ctx context.Context, path string) error {
+func (c SSHClient) packFiles(ctx context.Context, path string, checkSpace bool) error {
 	w := progress.ContextWriter(ctx)
 	w.Event(progress.Event{ID: "Files", StatusText: "Creating archive"})
 
-	excludeTarString := FormatIgnoredPath()
-	tarCmd := strings.Join([]string{"cd", c.Config.Catalog, "&&",
+	if checkSpace {
+		err := c.checkRemoteSpace("files archive", c.estimateFilesSize(path))
+		if err != nil {
+			return err
+		}
+	}
+
+	tarCmd := c.tarCommand(path, "production.tar.gz")
+	logrus.Infof("Run archiving files: %s", tarCmd)
+	return c.runContext(ctx, tarCmd)
+}
+
+// tarCommand Command archiving the paths into the file, "-" writes the archive to stdout
+func (c SSHClient) tarCommand(path, archive string) string {
+	return strings.Join([]string{"cd", c.Settings().Catalog, "&&",
 		"tar",
 		"--dereference",
 		"-zcf",
-		"production.tar.gz",
-		excludeTarString,
+		archive,
+		FormatIgnoredPath(),
 		path,
 	}, " ")
+}
+
+// streamFiles Extracting the archive on the fly from the SSH session, no files are created on the server.
+// Returns the size of the received archive.
+func (c SSHClient) streamFiles(ctx context.Context, path string) (int64, error) {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Files", StatusText: "Stream archive"})
+
+	tarCmd := c.tarCommand(path, "-")
 	logrus.Infof("Run archiving files: %s", tarCmd)
-	_, err := c.Run(tarCmd)
+	stream, err := c.Stream(tarCmd)
+	if err != nil {
+		return 0, err
+	}
+	defer func(stream client.RemoteStream) {
+		_ = stream.Close()
+	}(stream)
+	stop := context.AfterFunc(ctx, func() {
+		_ = stream.Close()
+	})
+	defer stop()
+
+	r := utils.NewProgressReader(stream, 0, func(_, _ int64) {})
+	err = extractFiles(ctx, r, func() string {
+		return utils.FormatProgress(r.BytesRead(), 0)
+	})
+	if err != nil {
+		return 0, err
+	}
 
+	// The stream must be read to the end before Wait
+	_, err = io.Copy(io.Discard, r)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	err = stream.Wait()
+	if err != nil {
+		return 0, err
+	}
+
+	return r.BytesRead(), nil
 }
 
 // FormatIgnoredPath Exclude path from tar
@@ -117,135 +218,76 @@ func (c SSHClient) downloadArchive(ctx context.Context) error {
 	w := progress.ContextWriter(ctx)
 	w.Event(progress.Event{ID: "Files", StatusText: "Download archive"})
 
-	serverPath := filepath.Join(c.Config.Catalog, "production.tar.gz")
+	serverPath := filepath.Join(c.Settings().Catalog, "production.tar.gz")
 	localPath := filepath.Join(Env.GetString("PWD"), "production.tar.gz")
 
 	logrus.Infof("Download archive: %s", serverPath)
-	err := c.Download(ctx, serverPath, localPath)
-
-	if err != nil {
-		w.Event(progress.ErrorMessageEvent("Files", fmt.Sprint(err)))
-		return err
-	}
-
-	logrus.Infof("Delete archive: %s", serverPath)
-	err = c.CleanRemote(serverPath)
-	if err != nil {
-		w.Event(progress.ErrorMessageEvent("Files", fmt.Sprint(err)))
-		return err
-	}
-
-	return err
+	return c.download(ctx, "Files", serverPath, localPath)
 }
 
-// ExtractArchive unzip the archive
+// ExtractArchive unzip the archive into the BACKEND_ROOT directory, the archive is deleted after extraction
 func ExtractArchive(ctx context.Context, path string) error {
-	var err error
 	w := progress.ContextWriter(ctx)
 	w.Event(progress.Event{ID: "Files", StatusText: "Extract archive"})
 
-	localPath := Env.GetString("PWD")
-	backPath := Env.GetString("BACKEND_ROOT")
-	destinationPath := localPath
-	if len(backPath) > 0 {
-		destinationPath = filepath.Join(localPath, backPath)
-	}
-	archive := filepath.Join(localPath, "production.tar.gz")
+	archive := filepath.Join(Env.GetString("PWD"), "production.tar.gz")
 	logrus.Infof("Extract archive local path: %s", archive)
 
-	_, err = os.Stat(destinationPath)
+	f, err := os.Open(archive)
 	if err != nil {
-		if err := os.MkdirAll(destinationPath, 0o775); err != nil {
-			w.Event(progress.ErrorMessageEvent("Files", fmt.Sprint(err)))
-			return err
-		}
+		return err
 	}
 
-	// TODO: rewrite to Go
-	outTar, err := exec.Command("tar", "-xzf", archive, "-C", destinationPath).CombinedOutput()
+	logrus.Infof("Extract paths: %s", path)
+	err = extractFiles(ctx, f, nil)
+	_ = f.Close()
 	if err != nil {
-		w.Event(progress.ErrorMessageEvent("Files", fmt.Sprint(string(outTar))))
 		return err
 	}
 
 	logrus.Infof("Delete archive path: %s", archive)
-	outRm, err := exec.Command("rm", "-f", archive).CombinedOutput()
+	return os.Remove(archive)
+}
+
+// extractFiles Extracting the archive from the reader into the BACKEND_ROOT directory, status adds text to the progress.
+// The archive is extracted into a temporary directory and moved to BACKEND_ROOT only when it is complete,
+// a failed or cancelled extraction leaves no partial files.
+func extractFiles(ctx context.Context, r io.Reader, status func() string) error {
+	w := progress.ContextWriter(ctx)
+
+	destinationPath := backendPath()
+	err := os.MkdirAll(destinationPath, 0o775)
 	if err != nil {
-		w.Event(progress.ErrorMessageEvent("Files", fmt.Sprint(string(outRm))))
 		return err
 	}
 
-	s := strings.Split(path, " ")
-	for _, dir := range s {
-		logrus.Infof("Run chmod 775: %s", dir)
-		chmodDir := filepath.Join(destinationPath, dir)
-		err = utils.ChmodR(chmodDir, 0775)
-		if err != nil {
-			w.Event(progress.ErrorMessageEvent("Files", fmt.Sprint(err)))
-			return err
-		}
+	// Directories left by an interrupted process
+	stale, _ := filepath.Glob(filepath.Join(destinationPath, extractDirPattern))
+	for _, dir := range stale {
+		logrus.Infof("Delete stale extract directory: %s", dir)
+		_ = os.RemoveAll(dir)
 	}
 
-	return nil
-}
-
-// BitrixAccess Change bitrix database accesses
-func (a *CallMethod) BitrixAccess() {
-	localPath := Env.GetString("PWD")
-	backPath := Env.GetString("BACKEND_ROOT")
-	destinationPath := localPath
-	if len(backPath) > 0 {
-		destinationPath = filepath.Join(localPath, backPath)
-	}
-	settingsFile := filepath.Join(destinationPath, "bitrix", ".settings.php")
-	dbconnFile := filepath.Join(destinationPath, "bitrix", "php_interface", "dbconn.php")
-
-	mysqlDB := Env.GetString("MYSQL_DATABASE")
-	mysqlUser := Env.GetString("MYSQL_USER")
-	mysqlPassword := Env.GetString("MYSQL_PASSWORD")
-
-	logrus.Infof("Replacing accesses in: %s", settingsFile)
-	err := exec.Command("sed", "-i", "-e", `/'debug' *\=> /c 'debug' => true,`,
-		"-e", `/'host' *\=> /c 'host' => 'db',`,
-		"-e", `/'database' *\=> /c 'database' => '`+mysqlDB+`',`,
-		"-e", `/'login' *\=> /c 'login' => '`+mysqlUser+`',`,
-		"-e", `/'password' *\=> /c 'password' => '`+mysqlPassword+`',`,
-		settingsFile).Run()
+	tmp, err := os.MkdirTemp(destinationPath, extractDirPattern)
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
-
-	logrus.Infof("Replacing accesses in: %s", dbconnFile)
-	err = exec.Command("sed", "-i", "-e", `/$DBHost /c $DBHost = \"db\";`,
-		"-e", `/$DBLogin /c $DBLogin = \"`+mysqlUser+`\";`,
-		"-e", `/$DBPassword /c $DBPassword = \"`+mysqlPassword+`\";`,
-		"-e", `/$DBName /c $DBName = \"`+mysqlDB+`\";`,
-		dbconnFile).Run()
-
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+
+	var count int
+	err = utils.ExtractTar(utils.NewContextReader(ctx, r), tmp, func(name string) {
+		count++
+		text := fmt.Sprintf("Extract archive: %d %s", count, name)
+		if status != nil {
+			text = fmt.Sprintf("Extract archive (%s): %d %s", status(), count, name)
+		}
+		w.Event(progress.Event{ID: "Files", StatusText: text})
+	})
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
-}
 
-// WordpressAccess Change WordPress database accesses
-func (a *CallMethod) WordpressAccess() {
-	var err error
-	localPath := Env.GetString("PWD")
-	settingsFile := filepath.Join(localPath, "wp-config.php")
-
-	mysqlDB := Env.GetString("MYSQL_DATABASE")
-	mysqlUser := Env.GetString("MYSQL_USER")
-	mysqlPassword := Env.GetString("MYSQL_PASSWORD")
-
-	logrus.Infof("Replacing accesses in: %s", settingsFile)
-	err = exec.Command("sed", "-i",
-		"-e", `/'DB_HOST'/c define('DB_HOST', 'db');`,
-		"-e", `/'DB_NAME'/c define('DB_NAME', '`+mysqlDB+`');`,
-		"-e", `/'DB_USER'/c define('DB_USER', '`+mysqlUser+`');`,
-		"-e", `/'DB_PASSWORD'/c define('DB_PASSWORD', '`+mysqlPassword+`');`,
-		settingsFile).Run()
-
-	if err != nil {
-		fmt.Println(err)
-	}
+	return utils.MergeDir(tmp, destinationPath)
 }
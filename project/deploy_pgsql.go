@@ -0,0 +1,93 @@
+package project
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/local-deploy/dl/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// pgDumpCommand PostgreSQL database dump in the custom format.
+// pg_dump cannot filter rows, so with filtered tables the dump is made in plain SQL
+// and the rows of the filtered tables are appended as COPY blocks made by psql.
+func (c SSHClient) pgDumpCommand(db *DBSettings, tables []string) string {
+	filters := db.dumpFilters(tables)
+	if len(filters) == 0 {
+		return strings.Join([]string{"cd", c.Settings().Catalog, "&&",
+			"PGPASSWORD=" + strconv.Quote(db.Password),
+			"pg_dump",
+			db.PgDumpParams(tables),
+			db.DataBase,
+		}, " ")
+	}
+
+	dumpCmd := []string{"cd", c.Settings().Catalog, "&&",
+		"export PGPASSWORD=" + strconv.Quote(db.Password), "&&",
+		"(",
+		"pg_dump",
+		db.PgDumpParams(tables),
+		db.DataBase,
+		// The plain dump resets search_path, triggers and foreign keys are not checked for the partial data
+		"&&", "echo", utils.ShellQuote("SET search_path = public, pg_catalog; SET session_replication_role = replica;"),
+	}
+	for _, filter := range filters {
+		query := "COPY (SELECT * FROM " + filter.Table + " WHERE " + filter.Condition() + ") TO STDOUT"
+		dumpCmd = append(dumpCmd,
+			"&&", "echo", utils.ShellQuote("COPY "+filter.Table+" FROM stdin;"),
+			"&&", "psql", "--host="+db.Host, "--port="+db.Port, "--username="+db.Login, "--quiet",
+			"--command="+utils.ShellQuote(query), db.DataBase,
+			"&&", "printf", utils.ShellQuote(`%s\n`), utils.ShellQuote(`\.`),
+		)
+	}
+
+	return strings.Join(append(dumpCmd, ")"), " ")
+}
+
+func (c SSHClient) checkPgDumpAvailable() error {
+	logrus.Info("Check if pg_dump available")
+	dumpCmd := strings.Join([]string{"cd", c.Settings().Catalog, "&&", "which pg_dump"}, " ")
+	logrus.Infof("Run command: %s", dumpCmd)
+	_, err := c.Run(dumpCmd)
+	if err != nil {
+		logrus.Info("pg_dump not available")
+		return err
+	}
+	logrus.Info("pg_dump available")
+	return nil
+}
+
+// PgDumpParams pg_dump options. If tables are specified, only they are dumped,
+// otherwise the data of the excluded tables is skipped, but their schema is kept.
+// The data of the filtered tables is skipped too, the dump is made in plain SQL for appending their rows.
+func (d DBSettings) PgDumpParams(tables []string) string {
+	params := []string{
+		"--host=" + d.Host,
+		"--port=" + d.Port,
+		"--username=" + d.Login,
+		"--format=custom",
+		"--no-owner",
+		"--no-acl",
+	}
+
+	if filters := d.dumpFilters(tables); len(filters) > 0 {
+		params[3] = "--format=plain"
+		params = append(params, "--clean", "--if-exists")
+		for _, table := range filteredTableNames(filters) {
+			params = append(params, "--exclude-table-data="+table)
+		}
+	}
+
+	if len(tables) > 0 {
+		for _, table := range tables {
+			params = append(params, "--table="+strings.TrimSpace(table))
+		}
+		return strings.Join(params, " ")
+	}
+
+	for _, table := range utils.CleanSlice(d.ExcludedTables) {
+		params = append(params, "--exclude-table-data="+strings.TrimSpace(table))
+	}
+
+	return strings.Join(params, " ")
+}
@@ -0,0 +1,337 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/utils/client"
+	"github.com/pkg/sftp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// syncWorkers number of files downloaded simultaneously
+const syncWorkers = 4
+
+// fileEntry file in the manifest of the synchronized directories
+type fileEntry struct {
+	size  int64
+	mtime int64
+	mode  os.FileMode
+	dir   bool
+	// skip symbolic link to a directory, it is neither downloaded nor deleted locally
+	skip bool
+}
+
+// SyncFiles Incremental synchronization of the paths with the server over SFTP.
+// Only new and changed (by size and modification time) files are downloaded,
+// if prune is true, local files that are not on the server are deleted. Returns the size of the downloaded files.
+func SyncFiles(ctx context.Context, client *client.Client, paths []string, prune bool) (int64, error) {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Files", StatusText: "Build manifest"})
+
+	ftp, err := client.NewSftp(sftp.UseConcurrentReads(true))
+	if err != nil {
+		return 0, err
+	}
+	defer func(ftp *sftp.Client) {
+		_ = ftp.Close()
+	}(ftp)
+
+	excluded := ExcludedFiles()
+	remote := make(map[string]fileEntry)
+	complete := true
+	for _, p := range paths {
+		ok, err := remoteManifest(ftp, client.Config.Catalog, p, excluded, remote)
+		if err != nil {
+			return 0, err
+		}
+		complete = complete && ok
+	}
+	if !complete && prune {
+		// Unread directories look empty, their local files would be deleted
+		logrus.Info("Some paths on the server were not read, local files are not deleted")
+		w.Event(progress.Event{ID: "Files", StatusText: "Some paths on the server were not read, --delete is skipped"})
+		prune = false
+	}
+
+	destination := backendPath()
+	local := make(map[string]fileEntry)
+	for _, p := range paths {
+		err = localManifest(destination, p, excluded, local)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	changed, dirs, size := diffManifests(remote, local)
+	for _, rel := range dirs {
+		err = os.MkdirAll(filepath.Join(destination, filepath.FromSlash(rel)), 0o775)
+		if err != nil {
+			return 0, err
+		}
+	}
+	logrus.Infof("Files on the server: %d, changed: %d", len(remote), len(changed))
+
+	var done atomic.Int64
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(syncWorkers)
+	for _, rel := range changed {
+		rel := rel
+		g.Go(func() error {
+			err := downloadFile(gctx, ftp, path.Join(client.Config.Catalog, rel), filepath.Join(destination, filepath.FromSlash(rel)), remote[rel])
+			if err != nil {
+				return fmt.Errorf("%s: %w", rel, err)
+			}
+			w.Event(progress.Event{ID: "Files", StatusText: fmt.Sprintf("Sync files: %d/%d", done.Add(1), len(changed))})
+			return nil
+		})
+	}
+	err = g.Wait()
+	if err != nil {
+		return 0, err
+	}
+
+	if !prune {
+		return size, nil
+	}
+
+	removed := prunePaths(remote, local)
+	for _, rel := range removed {
+		logrus.Infof("Delete local path: %s", rel)
+		err = os.RemoveAll(filepath.Join(destination, filepath.FromSlash(rel)))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return size, nil
+}
+
+// diffManifests files to download (new and changed by size and modification time),
+// directories missing locally and the size of the files to download
+func diffManifests(remote, local map[string]fileEntry) (changed, dirs []string, size int64) {
+	for rel, entry := range remote {
+		l, ok := local[rel]
+		if entry.skip {
+			continue
+		}
+		if entry.dir {
+			if !ok || !l.dir {
+				dirs = append(dirs, rel)
+			}
+			continue
+		}
+		if !ok || l.dir || l.size != entry.size || l.mtime != entry.mtime {
+			changed = append(changed, rel)
+			size += entry.size
+		}
+	}
+	slices.Sort(changed)
+	slices.Sort(dirs)
+
+	return changed, dirs, size
+}
+
+// prunePaths local paths missing on the server, files are deleted before directories, the longest paths go first
+func prunePaths(remote, local map[string]fileEntry) []string {
+	var removed []string
+	for rel := range local {
+		if _, ok := remote[rel]; !ok && !isSkippedPath(rel, remote) {
+			removed = append(removed, rel)
+		}
+	}
+	sortByLengthDesc(removed)
+
+	return removed
+}
+
+// remoteManifest list of files in the directory on the server. Returns false if some paths were not read,
+// then the manifest is incomplete and must not be used to delete local files.
+func remoteManifest(ftp *sftp.Client, catalog, dir string, excluded []string, manifest map[string]fileEntry) (bool, error) {
+	complete := true
+	walker := ftp.Walk(path.Join(catalog, dir))
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			logrus.Infof("Skipped: %s", err)
+			complete = false
+			continue
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), catalog), "/")
+		if IsExcludedFile(rel, excluded) {
+			if walker.Stat().IsDir() {
+				walker.SkipDir()
+			}
+			continue
+		}
+
+		info := walker.Stat()
+		if info.Mode()&os.ModeSymlink != 0 {
+			// Symbolic links are dereferenced as in tar --dereference, links to directories are skipped
+			target, err := ftp.Stat(walker.Path())
+			if err != nil || target.IsDir() {
+				logrus.Infof("Symbolic link skipped: %s", rel)
+				manifest[rel] = fileEntry{skip: true}
+				continue
+			}
+			info = target
+		}
+
+		manifest[rel] = fileEntry{
+			size:  info.Size(),
+			mtime: info.ModTime().Unix(),
+			mode:  info.Mode().Perm(),
+			dir:   info.IsDir(),
+		}
+	}
+
+	return complete, nil
+}
+
+// localManifest list of files in the local directory
+func localManifest(root, dir string, excluded []string, manifest map[string]fileEntry) error {
+	err := filepath.WalkDir(filepath.Join(root, filepath.FromSlash(dir)), func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if IsExcludedFile(rel, excluded) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		manifest[rel] = fileEntry{size: info.Size(), mtime: info.ModTime().Unix(), dir: d.IsDir()}
+
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// downloadFile Downloading the file to a temporary file next to the destination,
+// the modification time is taken from the server so that the file is not downloaded again
+func downloadFile(ctx context.Context, ftp *sftp.Client, remotePath, localPath string, entry fileEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := os.MkdirAll(filepath.Dir(localPath), 0o775)
+	if err != nil {
+		return err
+	}
+
+	remote, err := ftp.Open(remotePath)
+	if err != nil {
+		return err
+	}
+	defer remote.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(localPath), ".dl-sync-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	_, err = io.Copy(tmp, remote)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmp.Name(), entry.mode|0o600)
+	if err != nil {
+		return err
+	}
+
+	mtime := time.Unix(entry.mtime, 0)
+	err = os.Chtimes(tmp.Name(), mtime, mtime)
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Lstat(localPath); err == nil && info.IsDir() {
+		if err = os.RemoveAll(localPath); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(tmp.Name(), localPath)
+}
+
+// ExcludedFiles list of paths from the EXCLUDED_FILES variable
+func ExcludedFiles() []string {
+	var excluded []string
+	for _, p := range strings.Split(Env.GetString("EXCLUDED_FILES"), ",") {
+		p = strings.Trim(strings.TrimSpace(p), "/")
+		if len(p) > 0 {
+			excluded = append(excluded, p)
+		}
+	}
+
+	return excluded
+}
+
+// IsExcludedFile checking the relative path against the exclusion patterns as tar --exclude does:
+// the pattern matches the whole path, its beginning or the name of the file
+func IsExcludedFile(rel string, excluded []string) bool {
+	for _, pattern := range excluded {
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+
+		for p := rel; p != "." && p != "/"; p = path.Dir(p) {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isSkippedPath checking if the path is inside a skipped symbolic link
+func isSkippedPath(rel string, manifest map[string]fileEntry) bool {
+	for p := path.Dir(rel); p != "." && p != "/"; p = path.Dir(p) {
+		if manifest[p].skip {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortByLengthDesc sorting paths from longest to shortest
+func sortByLengthDesc(paths []string) {
+	slices.SortFunc(paths, func(a, b string) int {
+		return len(b) - len(a)
+	})
+}
@@ -0,0 +1,107 @@
+package project
+
+import (
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+func TestIsExcludedFile(t *testing.T) {
+	excluded := []string{"bitrix/cache", "*.log", "upload/resize_cache"}
+
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{rel: "bitrix/cache", want: true},
+		{rel: "bitrix/cache/css/style.css", want: true},
+		{rel: "bitrix/modules/main/error.log", want: true},
+		{rel: "bitrix/modules/main/include.php", want: false},
+		{rel: "bitrix/managed_cache/file", want: false},
+		{rel: "upload/resize_cache/1.jpg", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := IsExcludedFile(tt.rel, excluded); got != tt.want {
+			t.Errorf("IsExcludedFile(%q) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestDiffManifests(t *testing.T) {
+	remote := map[string]fileEntry{
+		"bitrix":             {dir: true},
+		"bitrix/new":         {dir: true},
+		"bitrix/same.php":    {size: 10, mtime: 100},
+		"bitrix/changed.php": {size: 20, mtime: 200},
+		"bitrix/added.php":   {size: 30, mtime: 300},
+		"bitrix/link":        {skip: true},
+	}
+	local := map[string]fileEntry{
+		"bitrix":             {dir: true},
+		"bitrix/same.php":    {size: 10, mtime: 100},
+		"bitrix/changed.php": {size: 20, mtime: 150},
+		"bitrix/removed.php": {size: 5, mtime: 50},
+		"bitrix/link/file":   {size: 1, mtime: 1},
+		"bitrix/old":         {dir: true},
+		"bitrix/old/1.php":   {size: 1, mtime: 1},
+	}
+
+	changed, dirs, size := diffManifests(remote, local)
+	if !slices.Equal(changed, []string{"bitrix/added.php", "bitrix/changed.php"}) || size != 50 {
+		t.Errorf("diffManifests() changed = %v, size = %d", changed, size)
+	}
+	if !slices.Equal(dirs, []string{"bitrix/new"}) {
+		t.Errorf("diffManifests() dirs = %v, want [bitrix/new]", dirs)
+	}
+
+	removed := prunePaths(remote, local)
+	if !slices.Equal(removed, []string{"bitrix/removed.php", "bitrix/old/1.php", "bitrix/old"}) {
+		t.Errorf("prunePaths() = %v, want the missing paths without skipped links, files first", removed)
+	}
+}
+
+func TestRemoteManifestIncomplete(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, sftp.InMemHandler())
+	go func() {
+		_ = server.Serve()
+	}()
+	t.Cleanup(func() {
+		_ = server.Close()
+	})
+
+	ftp, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = ftp.Close()
+	})
+
+	if err = ftp.MkdirAll("/site/bitrix"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := ftp.Create("/site/bitrix/index.php")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte("<?php"))
+	_ = f.Close()
+
+	manifest := make(map[string]fileEntry)
+	complete, err := remoteManifest(ftp, "/site", "bitrix", nil, manifest)
+	if err != nil || !complete {
+		t.Fatalf("remoteManifest() = %v, %v, want complete manifest", complete, err)
+	}
+	if manifest["bitrix/index.php"].size != 5 {
+		t.Errorf("remoteManifest() = %v", manifest)
+	}
+
+	complete, err = remoteManifest(ftp, "/site", "upload", nil, manifest)
+	if err != nil || complete {
+		t.Errorf("remoteManifest() = %v, %v, want incomplete manifest for unreadable path", complete, err)
+	}
+}
@@ -0,0 +1,310 @@
+package project
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// DumpCacheEntry dump of the server database saved in the cache
+type DumpCacheEntry struct {
+	Key       string    `json:"key"`
+	Source    string    `json:"source,omitempty"`
+	Server    string    `json:"server"`
+	Catalog   string    `json:"catalog"`
+	Database  string    `json:"database,omitempty"`
+	Engine    string    `json:"engine"`
+	Framework string    `json:"framework,omitempty"`
+	Tables    []string  `json:"tables,omitempty"`
+	Created   time.Time `json:"created"`
+	Size      int64     `json:"size"`
+}
+
+// DumpCacheDir directory of the cached dumps (~/.config/dl/dump-cache), it is shared by all projects
+func DumpCacheDir() string {
+	return filepath.Join(utils.ConfigDir(), "dump-cache")
+}
+
+// Path path to the dump file of the entry
+func (e DumpCacheEntry) Path() string {
+	ext := ".sql.gz"
+	if e.Engine == EnginePgsql {
+		ext = ".dump"
+	}
+
+	return filepath.Join(DumpCacheDir(), e.Key+ext)
+}
+
+// metaPath path to the description of the entry
+func (e DumpCacheEntry) metaPath() string {
+	return filepath.Join(DumpCacheDir(), e.Key+".json")
+}
+
+// newDumpCacheEntry entry of the dump of the current server and catalog. The key is made from the server,
+// the catalog, the database variables, the engine, the dumped, the excluded and the filtered tables, so the projects
+// and worktrees of the same site use the same entry.
+func newDumpCacheEntry(tables []string) DumpCacheEntry {
+	prefix := "MYSQL"
+	if DBEngine() == EnginePgsql {
+		prefix = "POSTGRES"
+	}
+
+	entry := DumpCacheEntry{
+		Source:   CurrentSource(),
+		Server:   Env.GetString("SERVER"),
+		Catalog:  Env.GetString("CATALOG_SRV"),
+		Database: Env.GetString(prefix + "_DATABASE_SRV"),
+		Engine:   DBEngine(),
+		Tables:   slices.Clone(tables),
+	}
+	slices.Sort(entry.Tables)
+	if len(entry.Server) == 0 {
+		entry.Server = Env.GetString("TELEPORT")
+	}
+
+	excluded := utils.CleanSlice(strings.Split(Env.GetString("EXCLUDED_TABLES"), ","))
+	slices.Sort(excluded)
+
+	hash := sha256.New()
+	for _, part := range []string{entry.Server, entry.Catalog, Env.GetString(prefix + "_HOST_SRV"), entry.Database,
+		entry.Engine, strings.Join(entry.Tables, ","), strings.Join(excluded, ","), Env.GetString("FILTERED_TABLES")} {
+		hash.Write([]byte(strings.TrimSpace(part) + "\x00"))
+	}
+	entry.Key = hex.EncodeToString(hash.Sum(nil))[:16]
+
+	return entry
+}
+
+// FindCachedDump cached dump of the current server not older than maxAge, nil if there is none
+func FindCachedDump(tables []string, maxAge time.Duration) (*DumpCacheEntry, error) {
+	entry := newDumpCacheEntry(tables)
+
+	data, err := os.ReadFile(entry.metaPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cached DumpCacheEntry
+	err = json.Unmarshal(data, &cached)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Since(cached.Created) > maxAge || !utils.PathExists(cached.Path()) {
+		logrus.Infof("Cached dump is expired: %s", cached.Path())
+		return nil, nil
+	}
+
+	return &cached, nil
+}
+
+// ImportCachedDump Importing the cached dump into a local container, returns the size of the dump
+func ImportCachedDump(ctx context.Context, entry *DumpCacheEntry) (int64, error) {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Database", Status: progress.Working,
+		StatusText: "Import cached dump from " + utils.FormatAgo(entry.Created, time.Now())})
+
+	f, err := os.Open(entry.Path())
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent("Database", err.Error()))
+		return 0, err
+	}
+	defer f.Close()
+
+	logrus.Infof("Import cached dump: %s", entry.Path())
+	_, err = importDump(ctx, entry.Framework, f, entry.Size)
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent("Database", err.Error()))
+		return 0, err
+	}
+
+	w.Event(progress.Event{ID: "Database", Status: progress.Done})
+	return entry.Size, nil
+}
+
+// cacheWriter Writing the dump to a temporary file in the cache, the entry is saved by Commit.
+// The cache is optional: a failed write discards the entry, but is not returned to the writer,
+// so the import reading the dump through io.TeeReader continues.
+type cacheWriter struct {
+	*os.File
+	entry DumpCacheEntry
+	// err the first write error, nothing is written after it
+	err error
+}
+
+// newCacheWriter starting a new cache entry of the current server
+func newCacheWriter(tables []string, db *DBSettings, fwType string) (*cacheWriter, error) {
+	err := utils.CreateDirectory(DumpCacheDir())
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(DumpCacheDir(), ".dump-*")
+	if err != nil {
+		return nil, err
+	}
+
+	entry := newDumpCacheEntry(tables)
+	entry.Database = db.DataBase
+	entry.Framework = fwType
+
+	return &cacheWriter{File: f, entry: entry}, nil
+}
+
+// Write writing to the temporary file, the error is saved and the entry is discarded
+func (w *cacheWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return len(p), nil
+	}
+
+	_, err := w.File.Write(p)
+	if err != nil {
+		logrus.Infof("Failed to save dump in the cache: %s", err)
+		w.err = err
+		w.Discard()
+	}
+
+	return len(p), nil
+}
+
+// Commit Saving the written dump as the cache entry
+func (w *cacheWriter) Commit() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	info, err := w.Stat()
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	w.entry.Created = time.Now()
+	w.entry.Size = info.Size()
+	data, err := json.Marshal(w.entry)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(w.Name(), w.entry.Path())
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Dump is saved in the cache: %s", w.entry.Path())
+	return os.WriteFile(w.entry.metaPath(), data, 0o644)
+}
+
+// Discard Deleting the temporary file
+func (w *cacheWriter) Discard() {
+	_ = w.Close()
+	_ = os.Remove(w.Name())
+}
+
+// cacheFile Copying the downloaded dump into the cache
+func cacheFile(path string, tables []string, db *DBSettings, fwType string) error {
+	w, err := newCacheWriter(tables, db, fwType)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		w.Discard()
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	if err != nil {
+		w.Discard()
+		return err
+	}
+
+	return w.Commit()
+}
+
+// DumpCache cached dumps, the newest first
+func DumpCache() ([]DumpCacheEntry, error) {
+	metas, err := filepath.Glob(filepath.Join(DumpCacheDir(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []DumpCacheEntry
+	for _, meta := range metas {
+		data, err := os.ReadFile(meta)
+		if err != nil {
+			return nil, err
+		}
+
+		var entry DumpCacheEntry
+		if err = json.Unmarshal(data, &entry); err != nil {
+			logrus.Infof("Skip cache entry %s: %s", meta, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Created.After(entries[j].Created)
+	})
+
+	return entries, nil
+}
+
+// ClearDumpCache Deleting the cached dumps older than maxAge, all dumps if maxAge is 0.
+// Returns the number of deleted dumps.
+func ClearDumpCache(maxAge time.Duration) (int, error) {
+	entries, err := DumpCache()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, entry := range entries {
+		if maxAge > 0 && time.Since(entry.Created) <= maxAge {
+			continue
+		}
+
+		logrus.Infof("Delete cached dump: %s", entry.Path())
+		err = os.Remove(entry.Path())
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return count, err
+		}
+		err = os.Remove(entry.metaPath())
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	// Temporary files of interrupted downloads
+	if maxAge == 0 {
+		tmp, _ := filepath.Glob(filepath.Join(DumpCacheDir(), ".dump-*"))
+		for _, path := range tmp {
+			_ = os.Remove(path)
+		}
+	}
+
+	return count, nil
+}
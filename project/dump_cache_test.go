@@ -0,0 +1,48 @@
+package project
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDumpCacheKey(t *testing.T) {
+	newTestEnv(t)
+	Env.Set("SERVER", "prod.example.com")
+	Env.Set("CATALOG_SRV", "/var/www/prod")
+	Env.Set("MYSQL_DATABASE_SRV", "site")
+
+	key := newDumpCacheEntry([]string{"b_user", "b_option"}).Key
+	if got := newDumpCacheEntry([]string{"b_option", "b_user"}).Key; got != key {
+		t.Errorf("key depends on the order of tables: %s != %s", got, key)
+	}
+	if got := newDumpCacheEntry(nil).Key; got == key {
+		t.Error("key of the full dump must differ from the key of the tables")
+	}
+
+	Env.Set("EXCLUDED_TABLES", "b_event_log")
+	if got := newDumpCacheEntry([]string{"b_user", "b_option"}).Key; got == key {
+		t.Error("key must depend on the excluded tables")
+	}
+}
+
+func TestCacheWriterFailure(t *testing.T) {
+	newTestConfigDir(t)
+	newTestEnv(t)
+
+	w, err := newCacheWriter(nil, &DBSettings{DataBase: "site"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Writes to the closed file fail as on a full disk
+	_ = w.File.Close()
+
+	if n, err := w.Write([]byte("dump")); n != 4 || err != nil {
+		t.Errorf("Write() = %d, %v, want the error not returned to the reader", n, err)
+	}
+	if err = w.Commit(); err == nil {
+		t.Error("Commit() expected error after a failed write")
+	}
+	if _, err = os.Stat(w.Name()); !os.IsNotExist(err) {
+		t.Errorf("temporary file is not deleted: %v", err)
+	}
+}
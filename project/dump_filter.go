@@ -0,0 +1,83 @@
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/local-deploy/dl/utils"
+)
+
+// TableFilter condition of the rows of the table included in the dump, the schema of the table is always dumped
+type TableFilter struct {
+	Table string
+	Where string
+}
+
+// maxRowsPattern the "column > (max-N)" shorthand: the last N values of the column
+var maxRowsPattern = regexp.MustCompile(`(?i)([\w.]+)\s*(>=|>)\s*\(\s*max\s*-\s*(\d+)\s*\)`)
+
+// TableFilters filters from the FILTERED_TABLES variable: "table:condition" entries separated by semicolons,
+// for example "b_event_log:ID > (max-10000);b_sale_order:DATE_INSERT > NOW() - INTERVAL 90 DAY"
+func TableFilters() ([]TableFilter, error) {
+	var filters []TableFilter
+	for _, entry := range strings.Split(Env.GetString("FILTERED_TABLES"), ";") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		table, where, found := strings.Cut(entry, ":")
+		table, where = strings.TrimSpace(table), strings.TrimSpace(where)
+		if !found || len(table) == 0 || len(where) == 0 {
+			return nil, fmt.Errorf("invalid FILTERED_TABLES entry %q, expected table:condition", entry)
+		}
+
+		filters = append(filters, TableFilter{Table: table, Where: where})
+	}
+
+	return filters, nil
+}
+
+// Condition WHERE condition of the filter, "column > (max-N)" is replaced with a subquery of the maximum value
+func (f TableFilter) Condition() string {
+	return maxRowsPattern.ReplaceAllStringFunc(f.Where, func(s string) string {
+		m := maxRowsPattern.FindStringSubmatch(s)
+		n, _ := strconv.ParseInt(m[3], 10, 64)
+		return fmt.Sprintf("%s %s (SELECT MAX(%s) - %d FROM %s)", m[1], m[2], m[1], n, f.Table)
+	})
+}
+
+// dumpFilters filters of the dumped tables: the filters of excluded tables are skipped,
+// if the tables are specified, only their filters are used
+func (d DBSettings) dumpFilters(tables []string) []TableFilter {
+	excluded := utils.CleanSlice(d.ExcludedTables)
+	for i := range excluded {
+		excluded[i] = strings.TrimSpace(excluded[i])
+	}
+
+	var filters []TableFilter
+	for _, filter := range d.FilteredTables {
+		if len(tables) > 0 && !slices.Contains(tables, filter.Table) {
+			continue
+		}
+		if len(tables) == 0 && slices.Contains(excluded, filter.Table) {
+			continue
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters
+}
+
+// filteredTableNames names of the filtered tables
+func filteredTableNames(filters []TableFilter) []string {
+	names := make([]string, len(filters))
+	for i, filter := range filters {
+		names[i] = filter.Table
+	}
+
+	return names
+}
@@ -0,0 +1,61 @@
+package project
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableFilters(t *testing.T) {
+	newTestEnv(t)
+	Env.Set("FILTERED_TABLES", "b_event_log:ID > (max-10000); b_sale_order : DATE_INSERT > NOW() - INTERVAL 90 DAY;")
+
+	filters, err := TableFilters()
+	if err != nil {
+		t.Fatalf("TableFilters() error = %v", err)
+	}
+	if len(filters) != 2 || filters[1].Table != "b_sale_order" || filters[1].Where != "DATE_INSERT > NOW() - INTERVAL 90 DAY" {
+		t.Fatalf("TableFilters() = %v", filters)
+	}
+
+	if got, want := filters[0].Condition(), "ID > (SELECT MAX(ID) - 10000 FROM b_event_log)"; got != want {
+		t.Errorf("Condition() = %s, want %s", got, want)
+	}
+	if got := filters[1].Condition(); got != filters[1].Where {
+		t.Errorf("Condition() = %s, want the condition unchanged", got)
+	}
+
+	Env.Set("FILTERED_TABLES", "b_event_log")
+	if _, err = TableFilters(); err == nil {
+		t.Error("TableFilters() expected error for the entry without condition")
+	}
+}
+
+func TestDumpFilters(t *testing.T) {
+	db := DBSettings{
+		DataBase:       "site",
+		ExcludedTables: []string{"b_search_content", " b_perf_error"},
+		FilteredTables: []TableFilter{
+			{Table: "b_event_log", Where: "ID > 10"},
+			{Table: "b_perf_error", Where: "ID > 10"},
+		},
+	}
+
+	if got := filteredTableNames(db.dumpFilters(nil)); len(got) != 1 || got[0] != "b_event_log" {
+		t.Errorf("dumpFilters(nil) = %v, want the filters of not excluded tables", got)
+	}
+	if got := filteredTableNames(db.dumpFilters([]string{"b_perf_error", "b_user"})); len(got) != 1 || got[0] != "b_perf_error" {
+		t.Errorf("dumpFilters(tables) = %v, want the filters of the specified tables", got)
+	}
+
+	dump := mysqlFilteredDump("--no-create-info", db.DataBase, db.dumpFilters(nil))
+	if want := "&& mysqldump --no-create-info --where='ID > 10' site b_event_log"; dump != want {
+		t.Errorf("mysqlFilteredDump() = %s, want %s", dump, want)
+	}
+
+	params := db.PgDumpParams(nil)
+	for _, want := range []string{"--format=plain", "--exclude-table-data=b_event_log", "--exclude-table-data=b_search_content"} {
+		if !strings.Contains(params, want) {
+			t.Errorf("PgDumpParams() = %s, want %s", params, want)
+		}
+	}
+}
@@ -17,6 +17,12 @@ import (
 // Env Project variables
 var Env *viper.Viper
 
+// Supported database engines
+const (
+	EngineMysql = "mysql"
+	EnginePgsql = "pgsql"
+)
+
 var phpImagesVersion = map[string]string{
 	"7.3-apache": "1.1.3",
 	"7.3-fpm":    "1.0.3",
@@ -110,6 +116,13 @@ func setDefaultEnv() {
 	Env.SetDefault("MYSQL_USER", "db")
 	Env.SetDefault("MYSQL_PASSWORD", "db")
 	Env.SetDefault("MYSQL_ROOT_PASSWORD", "root")
+
+	Env.SetDefault("POSTGRES_HOST_SRV", "localhost")
+	Env.SetDefault("POSTGRES_PORT_SRV", "5432")
+
+	Env.SetDefault("POSTGRES_DB", "db")
+	Env.SetDefault("POSTGRES_USER", "db")
+	Env.SetDefault("POSTGRES_PASSWORD", "db")
 }
 
 // setComposeFile Set docker-compose files
@@ -161,6 +174,36 @@ func setComposeFiles() {
 	Env.SetDefault("COMPOSE_FILE", containers)
 }
 
+// DBEngine Local database engine: pgsql if only PostgreSQL is enabled, otherwise mysql (MySQL or MariaDB)
+func DBEngine() string {
+	if len(Env.GetString("POSTGRES_VERSION")) > 0 &&
+		len(Env.GetString("MYSQL_VERSION")) == 0 &&
+		len(Env.GetString("MARIADB_VERSION")) == 0 {
+		return EnginePgsql
+	}
+
+	return EngineMysql
+}
+
+// DBContainer Name of the local database container
+func DBContainer() string {
+	site := Env.GetString("HOST_NAME")
+	if DBEngine() == EnginePgsql {
+		return site + "_pgsql"
+	}
+
+	return site + "_db"
+}
+
+// DBService Name of the local database service in docker-compose files
+func DBService() string {
+	if DBEngine() == EnginePgsql {
+		return "postgres"
+	}
+
+	return "db"
+}
+
 func getNginxConf() string {
 	var configNginxFile string
 
@@ -0,0 +1,33 @@
+package project
+
+import (
+	"testing"
+
+	"github.com/local-deploy/dl/utils"
+	"github.com/spf13/viper"
+)
+
+// newTestEnv replacing the project variables for the test, they are restored when the test ends
+func newTestEnv(t *testing.T) *viper.Viper {
+	saved := Env
+	Env = viper.New()
+	t.Cleanup(func() {
+		Env = saved
+	})
+
+	return Env
+}
+
+// newTestConfigDir replacing the user config directory with a temporary directory for the test
+func newTestConfigDir(t *testing.T) string {
+	dir := t.TempDir()
+	saved := utils.UserConfigDir
+	utils.UserConfigDir = func() (string, error) {
+		return dir, nil
+	}
+	t.Cleanup(func() {
+		utils.UserConfigDir = saved
+	})
+
+	return dir
+}
@@ -0,0 +1,134 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Framework support of a framework or CMS in deploy.
+// Implementations are listed in frameworks.
+type Framework interface {
+	// Name unique framework name, it is stored in client.Config.FwType
+	Name() string
+	// Title human-readable framework name
+	Title() string
+	// Detect checks the list of files in the root directory of the site on the server
+	Detect(files []string) bool
+	// DBSettings reading database accesses from the site configuration on the server
+	DBSettings(c SSHClient) (*DBSettings, error)
+	// Paths directories downloaded from the server by default
+	Paths() []string
+	// UpdateConfig changing database accesses in the local site configuration after deploy
+	UpdateConfig() error
+	// ConfigFiles files changed by UpdateConfig, they are never uploaded to the server
+	ConfigFiles() []string
+	// PostImport changing the local database after import
+	PostImport(ctx context.Context) error
+	// AnonymizeRules built-in anonymization profile for the tables of the local database
+	AnonymizeRules(ctx context.Context) ([]AnonymizeRule, error)
+	// PrintInfo displaying additional information after deploy
+	PrintInfo()
+}
+
+// PushReplacer framework whose local database contains the local site addresses,
+// they are replaced with the addresses of the server in the dump uploaded by PushDB
+type PushReplacer interface {
+	PushReplace(ctx context.Context, c SSHClient, db *DBSettings) (*SearchReplace, error)
+}
+
+// frameworks supported frameworks in the detection order, the first detected one is used
+var frameworks = []Framework{bitrix{}, wordpress{}, laravel{}}
+
+// GetFramework supported framework by name, nil if not found
+func GetFramework(name string) Framework {
+	for _, f := range frameworks {
+		if f.Name() == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// DetectFramework determining the framework by the files in the site directory on the server.
+// Returns nil if the framework is not detected.
+func (c SSHClient) DetectFramework() (Framework, error) {
+	ls := strings.Join([]string{"cd", c.Settings().Catalog, "&&", "ls -a"}, " ")
+	logrus.Infof("Run command: %s", ls)
+	out, err := c.Run(ls)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Info("Detect Framework")
+	files := strings.Fields(string(out))
+	for _, f := range frameworks {
+		if f.Detect(files) {
+			fmt.Printf("%s detected\n", f.Title())
+			return f, nil
+		}
+	}
+
+	logrus.Errorf("Output of ls: %s", string(out))
+
+	return nil, nil
+}
+
+// containsFile checking for the file in the list of files
+func containsFile(files []string, name string) bool {
+	return slices.Contains(files, name)
+}
+
+// backendPath local path to the site root (PWD/BACKEND_ROOT)
+func backendPath() string {
+	localPath := Env.GetString("PWD")
+	backPath := Env.GetString("BACKEND_ROOT")
+	if len(backPath) > 0 {
+		return filepath.Join(localPath, backPath)
+	}
+
+	return localPath
+}
+
+// lineReplacement replacing the whole line matching the pattern with the text
+type lineReplacement struct {
+	pattern string
+	text    string
+}
+
+// replaceLines Replacing lines of the file, the indentation of the replaced line is kept
+func replaceLines(file string, replacements []lineReplacement) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for _, r := range replacements {
+		re, err := regexp.Compile(r.pattern)
+		if err != nil {
+			return err
+		}
+
+		for i, line := range lines {
+			if re.MatchString(line) {
+				indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+				lines[i] = indent + r.text
+			}
+		}
+	}
+
+	return os.WriteFile(file, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+}
@@ -0,0 +1,148 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/local-deploy/dl/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// bitrix Bitrix CMS
+type bitrix struct{}
+
+func (bitrix) Name() string {
+	return "bitrix"
+}
+
+func (bitrix) Title() string {
+	return "Bitrix CMS"
+}
+
+func (bitrix) Detect(files []string) bool {
+	return containsFile(files, "bitrix")
+}
+
+// DBSettings Attempt to determine database accesses
+func (bitrix) DBSettings(c SSHClient) (*DBSettings, error) {
+	var catCmd string
+	if len(remotePhpPath) > 0 {
+		// A more precise way to define variables
+		catCmd = strings.Join([]string{"cd", c.Settings().Catalog, "&&",
+			`$(which php) -r '$settings = include "bitrix/.settings.php"; echo $settings["connections"]["value"]["default"]["host"]."\n";
+echo $settings["connections"]["value"]["default"]["database"]."\n";
+echo $settings["connections"]["value"]["default"]["login"]."\n";
+echo $settings["connections"]["value"]["default"]["password"]."\n";'`,
+		}, " ")
+	} else {
+		// Defining variables with grep
+		catCmd = strings.Join([]string{"cd", c.Settings().Catalog, "&&",
+			`cat bitrix/.settings.php | grep "'host' *\=>" | awk '{print $3}' | sed -e 's/^.\{1\}//' | sed 's/^\(.*\).$/\1/' | sed 's/^\(.*\).$/\1/'`, "&&",
+			`cat bitrix/.settings.php | grep "'database' *\=>" | awk '{print $3}' | sed -e 's/^.\{1\}//' | sed 's/^\(.*\).$/\1/' | sed 's/^\(.*\).$/\1/'`, "&&",
+			`cat bitrix/.settings.php | grep "'login' *\=>" | awk '{print $3}' | sed -e 's/^.\{1\}//' | sed 's/^\(.*\).$/\1/' | sed 's/^\(.*\).$/\1/'`, "&&",
+			`cat bitrix/.settings.php | grep "'password' *\=>" | awk '{print $3}' | sed -e 's/^.\{1\}//' | sed 's/^\(.*\).$/\1/' | sed 's/^\(.*\).$/\1/'`,
+		}, " ")
+	}
+
+	logrus.Infof("Run command: %s", catCmd)
+	cat, err := c.Run(catCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	dbArray := utils.CleanSlice(strings.Split(strings.TrimSpace(string(cat)), "\n"))
+	logrus.Infof("Received variables: %s", dbArray)
+	if len(dbArray) != 4 {
+		return nil, errors.New("failed to define DB variables, please specify accesses manually")
+	}
+
+	return &DBSettings{
+		Host:     dbArray[0],
+		DataBase: dbArray[1],
+		Login:    dbArray[2],
+		Password: dbArray[3],
+	}, err
+}
+
+func (bitrix) Paths() []string {
+	return []string{"bitrix"}
+}
+
+// UpdateConfig Change bitrix database accesses
+func (bitrix) UpdateConfig() error {
+	destinationPath := backendPath()
+	settingsFile := filepath.Join(destinationPath, "bitrix", ".settings.php")
+	dbconnFile := filepath.Join(destinationPath, "bitrix", "php_interface", "dbconn.php")
+
+	mysqlDB := Env.GetString("MYSQL_DATABASE")
+	mysqlUser := Env.GetString("MYSQL_USER")
+	mysqlPassword := Env.GetString("MYSQL_PASSWORD")
+
+	// The settings are absent if they were not downloaded, for example with the override of the paths
+	if utils.PathExists(settingsFile) {
+		logrus.Infof("Replacing accesses in: %s", settingsFile)
+		err := replaceLines(settingsFile, []lineReplacement{
+			{`'debug' *=> `, `'debug' => true,`},
+			{`'host' *=> `, `'host' => 'db',`},
+			{`'database' *=> `, `'database' => '` + mysqlDB + `',`},
+			{`'login' *=> `, `'login' => '` + mysqlUser + `',`},
+			{`'password' *=> `, `'password' => '` + mysqlPassword + `',`},
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		logrus.Infof("Bitrix settings file not found: %s", settingsFile)
+	}
+
+	// dbconn.php is absent in new versions
+	if !utils.PathExists(dbconnFile) {
+		return nil
+	}
+
+	logrus.Infof("Replacing accesses in: %s", dbconnFile)
+	return replaceLines(dbconnFile, []lineReplacement{
+		{`\$DBHost `, `$DBHost = "db";`},
+		{`\$DBLogin `, `$DBLogin = "` + mysqlUser + `";`},
+		{`\$DBPassword `, `$DBPassword = "` + mysqlPassword + `";`},
+		{`\$DBName `, `$DBName = "` + mysqlDB + `";`},
+	})
+}
+
+func (bitrix) ConfigFiles() []string {
+	return []string{"bitrix/.settings.php", "bitrix/php_interface/dbconn.php"}
+}
+
+// PostImport Setting the local domains of the site
+func (bitrix) PostImport(ctx context.Context) error {
+	site := Env.GetString("HOST_NAME")
+	local := Env.GetString("LOCAL_DOMAIN")
+	nip := Env.GetString("NIP_DOMAIN")
+
+	sql := `UPDATE b_option SET VALUE = 'Y' WHERE MODULE_ID = 'main' AND NAME = 'update_devsrv';
+UPDATE b_lang SET SERVER_NAME='` + site + `.localhost' WHERE LID='s1';
+UPDATE b_lang SET b_lang.DOC_ROOT='' WHERE 1=(SELECT DOC_ROOT FROM (SELECT COUNT(LID) FROM b_lang) as cnt);
+INSERT IGNORE INTO b_lang_domain VALUES ('s1', '` + local + `');
+INSERT IGNORE INTO b_lang_domain VALUES ('s1', '` + nip + `');`
+
+	return execLocalSQL(ctx, strings.NewReader(sql), nil)
+}
+
+func (bitrix) AnonymizeRules(_ context.Context) ([]AnonymizeRule, error) {
+	return []AnonymizeRule{
+		{Table: "b_user", Column: "EMAIL", Method: AnonymizeEmail, Where: "ID <> 1"},
+		{Table: "b_user", Column: "LOGIN", Method: AnonymizeHash, Where: "ID <> 1"},
+		{Table: "b_user", Column: "NAME", Method: AnonymizeFixed, Value: "User", Where: "ID <> 1"},
+		{Table: "b_user", Column: "LAST_NAME", Method: AnonymizeNull, Where: "ID <> 1"},
+		{Table: "b_user", Column: "SECOND_NAME", Method: AnonymizeNull, Where: "ID <> 1"},
+		{Table: "b_user", Column: "PERSONAL_PHONE", Method: AnonymizeNull, Where: "ID <> 1"},
+		{Table: "b_user", Column: "PERSONAL_MOBILE", Method: AnonymizeNull, Where: "ID <> 1"},
+		{Table: "b_user", Column: "PERSONAL_STREET", Method: AnonymizeNull, Where: "ID <> 1"},
+		{Table: "b_user", Column: "PERSONAL_BIRTHDAY", Method: AnonymizeNull, Where: "ID <> 1"},
+		{Table: "b_user", Column: "WORK_PHONE", Method: AnonymizeNull, Where: "ID <> 1"},
+	}, nil
+}
+
+func (bitrix) PrintInfo() {}
@@ -0,0 +1,169 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/local-deploy/dl/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// laravel Laravel framework
+type laravel struct{}
+
+func (laravel) Name() string {
+	return "laravel"
+}
+
+func (laravel) Title() string {
+	return "Laravel FW"
+}
+
+func (laravel) Detect(files []string) bool {
+	return containsFile(files, "artisan")
+}
+
+// DBSettings Attempt to determine database accesses
+func (laravel) DBSettings(c SSHClient) (*DBSettings, error) {
+	catCmd := strings.Join([]string{"cd", c.Settings().Catalog, "&&", "export $(grep -v '^#' .env | xargs)", "&&",
+		`echo $DB_HOST`, "&&",
+		`echo $DB_DATABASE`, "&&",
+		`echo $DB_USERNAME`, "&&",
+		`echo $DB_PASSWORD`,
+	}, " ")
+	logrus.Infof("Run command: %s", catCmd)
+	cat, err := c.Run(catCmd)
+
+	dbArray := strings.Split(strings.TrimSpace(string(cat)), "\n")
+	logrus.Infof("Received variables: %s", dbArray)
+	if len(dbArray) != 4 {
+		return nil, errors.New("failed to define DB variables, please specify accesses manually")
+	}
+
+	return &DBSettings{
+		Host:     dbArray[0],
+		DataBase: dbArray[1],
+		Login:    dbArray[2],
+		Password: dbArray[3],
+	}, err
+}
+
+// Paths only the database is downloaded
+func (laravel) Paths() []string {
+	return nil
+}
+
+// UpdateConfig Change the database, cache and URL settings in the Laravel .env file
+func (laravel) UpdateConfig() error {
+	envFile := filepath.Join(backendPath(), ".env")
+	if !utils.PathExists(envFile) {
+		logrus.Infof("Laravel environment file not found: %s", envFile)
+		return nil
+	}
+
+	values := [][2]string{
+		{"APP_URL", "http://" + Env.GetString("LOCAL_DOMAIN")},
+		{"DB_HOST", DBService()},
+	}
+	if DBEngine() == EnginePgsql {
+		values = append(values,
+			[2]string{"DB_CONNECTION", "pgsql"},
+			[2]string{"DB_PORT", "5432"},
+			[2]string{"DB_DATABASE", Env.GetString("POSTGRES_DB")},
+			[2]string{"DB_USERNAME", Env.GetString("POSTGRES_USER")},
+			[2]string{"DB_PASSWORD", Env.GetString("POSTGRES_PASSWORD")},
+		)
+	} else {
+		values = append(values,
+			[2]string{"DB_CONNECTION", "mysql"},
+			[2]string{"DB_PORT", "3306"},
+			[2]string{"DB_DATABASE", Env.GetString("MYSQL_DATABASE")},
+			[2]string{"DB_USERNAME", Env.GetString("MYSQL_USER")},
+			[2]string{"DB_PASSWORD", Env.GetString("MYSQL_PASSWORD")},
+		)
+	}
+
+	// CACHE_DRIVER was renamed to CACHE_STORE in Laravel 11
+	switch {
+	case Env.GetBool("REDIS"):
+		values = append(values,
+			[2]string{"REDIS_HOST", "redis"},
+			[2]string{"REDIS_PASSWORD", Env.GetString("REDIS_PASSWORD")},
+			[2]string{"REDIS_PORT", "6379"},
+			[2]string{"CACHE_DRIVER", "redis"},
+			[2]string{"CACHE_STORE", "redis"},
+		)
+	case Env.GetBool("MEMCACHED"):
+		values = append(values,
+			[2]string{"MEMCACHED_HOST", "memcached"},
+			[2]string{"CACHE_DRIVER", "memcached"},
+			[2]string{"CACHE_STORE", "memcached"},
+		)
+	}
+
+	logrus.Infof("Replacing accesses in: %s", envFile)
+	return setEnvValues(envFile, values)
+}
+
+// setEnvValues Replacing variables in the env file, missing variables are added to the end of the file
+func setEnvValues(file string, values [][2]string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	for _, v := range values {
+		line := v[0] + "=" + envQuote(v[1])
+
+		found := false
+		for i, l := range lines {
+			key, _, ok := strings.Cut(strings.TrimSpace(l), "=")
+			if ok && strings.TrimSpace(strings.TrimPrefix(key, "export ")) == v[0] {
+				lines[i] = line
+				found = true
+			}
+		}
+		if !found {
+			lines = append(lines, line)
+		}
+	}
+
+	return os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), info.Mode().Perm())
+}
+
+// envQuote quoting the value if it contains spaces or special characters
+func envQuote(s string) string {
+	if strings.ContainsAny(s, " \t#\"'$\\") {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(s) + `"`
+	}
+
+	return s
+}
+
+func (laravel) ConfigFiles() []string {
+	return []string{".env"}
+}
+
+func (laravel) PostImport(context.Context) error {
+	return nil
+}
+
+func (laravel) AnonymizeRules(_ context.Context) ([]AnonymizeRule, error) {
+	return []AnonymizeRule{
+		{Table: "users", Column: "email", Method: AnonymizeEmail},
+		{Table: "users", Column: "name", Method: AnonymizeFixed, Value: "User"},
+		{Table: "users", Column: "password", Method: AnonymizeFixed, Value: laravelPassword},
+		{Table: "users", Column: "remember_token", Method: AnonymizeNull},
+	}, nil
+}
+
+func (laravel) PrintInfo() {}
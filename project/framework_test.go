@@ -0,0 +1,160 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplaceLines(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".settings.php")
+	content := "<?php\nreturn [\n\t\t'host' => 'localhost',\n\t\t'login' => 'user',\n];\n"
+	err := os.WriteFile(file, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = replaceLines(file, []lineReplacement{
+		{`'host' *=> `, `'host' => 'db',`},
+		{`'login' *=> `, `'login' => 'db',`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := os.ReadFile(file)
+	want := "<?php\nreturn [\n\t\t'host' => 'db',\n\t\t'login' => 'db',\n];\n"
+	if string(got) != want {
+		t.Errorf("replaceLines() = %q, want %q", got, want)
+	}
+}
+
+func TestFrameworkDetect(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  string
+	}{
+		{files: []string{".", "..", "bitrix", "upload", "index.php"}, want: "bitrix"},
+		{files: []string{"wp-admin", "wp-config.php", "wp-includes"}, want: "wordpress"},
+		{files: []string{"app", "artisan", "composer.json"}, want: "laravel"},
+		{files: []string{"artisan", "bitrix", "wp-config.php"}, want: "bitrix"},
+		{files: []string{"artisan", "wp-config.php"}, want: "wordpress"},
+		{files: []string{"index.php", "bitrix.txt"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		var got string
+		for _, f := range frameworks {
+			if f.Detect(tt.files) {
+				got = f.Name()
+				break
+			}
+		}
+		if got != tt.want {
+			t.Errorf("detect %v = %q, want %q", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestSetEnvValues(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	content := "APP_NAME=Laravel\n# DB_HOST=old\nDB_HOST=prod.example.com\nexport DB_PASSWORD=secret\n"
+	err := os.WriteFile(file, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = setEnvValues(file, [][2]string{
+		{"DB_HOST", "db"},
+		{"DB_PASSWORD", "p@ss word"},
+		{"CACHE_DRIVER", "redis"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := os.ReadFile(file)
+	want := "APP_NAME=Laravel\n# DB_HOST=old\nDB_HOST=db\nDB_PASSWORD=\"p@ss word\"\nCACHE_DRIVER=redis\n"
+	if string(got) != want {
+		t.Errorf("setEnvValues() = %q, want %q", got, want)
+	}
+}
+
+func TestSearchReplace(t *testing.T) {
+	sr := newHostReplace("prod.ru", "http://site.localhost", "site.localhost")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain text",
+			in:   `INSERT INTO wp_posts VALUES (1,'<a href=\"https://prod.ru/page\">page</a>');`,
+			want: `INSERT INTO wp_posts VALUES (1,'<a href=\"http://site.localhost/page\">page</a>');`,
+		},
+		{
+			name: "serialized string",
+			in:   `INSERT INTO wp_options VALUES (1,'widget','a:1:{s:3:\"url\";s:19:\"https://prod.ru/img\";}');`,
+			want: `INSERT INTO wp_options VALUES (1,'widget','a:1:{s:3:\"url\";s:25:\"http://site.localhost/img\";}');`,
+		},
+		{
+			name: "escaped quotes in serialized string",
+			in:   `('a:1:{i:0;s:20:\"say \"hi\" //prod.ru/x\";}')`,
+			want: `('a:1:{i:0;s:27:\"say \"hi\" //site.localhost/x\";}')`,
+		},
+		{
+			name: "nested serialized string",
+			in:   `('s:33:\"a:1:{i:0;s:15:\"https://prod.ru\";}\";')`,
+			want: `('s:39:\"a:1:{i:0;s:21:\"http://site.localhost\";}\";')`,
+		},
+		{
+			name: "longer host name is left as is",
+			in:   `('<img src=\"//prod.ru.cdn.net/a.png\">','https://prod.ru-old.com')`,
+			want: `('<img src=\"//prod.ru.cdn.net/a.png\">','https://prod.ru-old.com')`,
+		},
+		{
+			name: "port and end of string",
+			in:   `('http://prod.ru:8080/a','//prod.ru')`,
+			want: `('http://site.localhost:8080/a','//site.localhost')`,
+		},
+		{
+			name: "broken length is left as is",
+			in:   `('s:99:\"https://prod.ru\";')`,
+			want: `('s:99:\"http://site.localhost\";')`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sr.Replace(tt.in, true); got != tt.want {
+				t.Errorf("Replace() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWpReplaceRows(t *testing.T) {
+	out := "wp_options\toption_value\t1\nwp_options\toption_name\t1\nwp_log\tmessage\t0\n"
+	columns := parseTextColumns(out)
+	if len(columns) != 2 || columns["wp_log"] != nil || len(columns["wp_options"]) != 2 {
+		t.Fatalf("parseTextColumns() = %v", columns)
+	}
+
+	got := wpHostCondition(columns["wp_options"], "my_site.ru")
+	want := "`option_value` LIKE '%//my\\\\_site.ru%' OR `option_name` LIKE '%//my\\\\_site.ru%'"
+	if got != want {
+		t.Errorf("wpHostCondition() = %s, want %s", got, want)
+	}
+}
+
+func TestUpdateConfigMissingFiles(t *testing.T) {
+	newTestEnv(t)
+	Env.Set("PWD", t.TempDir())
+
+	for _, fw := range frameworks {
+		if err := fw.UpdateConfig(); err != nil {
+			t.Errorf("%s UpdateConfig() error = %v, want the missing config skipped", fw.Name(), err)
+		}
+	}
+}
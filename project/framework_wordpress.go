@@ -0,0 +1,390 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/utils"
+	"github.com/pterm/pterm"
+	"github.com/sirupsen/logrus"
+)
+
+// wordpress WordPress CMS
+type wordpress struct{}
+
+func (wordpress) Name() string {
+	return "wordpress"
+}
+
+func (wordpress) Title() string {
+	return "WordPress CMS"
+}
+
+func (wordpress) Detect(files []string) bool {
+	return containsFile(files, "wp-config.php")
+}
+
+// DBSettings Attempt to determine database accesses
+func (wordpress) DBSettings(c SSHClient) (*DBSettings, error) {
+	catCmd := strings.Join([]string{"cd", c.Settings().Catalog, "&&",
+		`$(which php) -r 'error_reporting(0); define("SHORTINIT",true); $settings = include "wp-config.php"; echo DB_HOST."\n"; echo DB_NAME."\n"; echo DB_USER."\n"; echo DB_PASSWORD."\n";'`,
+	}, " ")
+	logrus.Infof("Run command: %s", catCmd)
+	cat, err := c.Run(catCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	dbArray := strings.Split(strings.TrimSpace(string(cat)), "\n")
+	logrus.Infof("Received variables: %s", dbArray)
+	if len(dbArray) != 4 {
+		return nil, errors.New("failed to define DB variables, please specify accesses manually")
+	}
+
+	return &DBSettings{
+		Host:     dbArray[0],
+		DataBase: dbArray[1],
+		Login:    dbArray[2],
+		Password: dbArray[3],
+	}, err
+}
+
+func (wordpress) Paths() []string {
+	return []string{"wp-admin", "wp-includes"}
+}
+
+// UpdateConfig Change WordPress database accesses and site address
+func (wordpress) UpdateConfig() error {
+	settingsFile := filepath.Join(Env.GetString("PWD"), "wp-config.php")
+	if !utils.PathExists(settingsFile) {
+		logrus.Infof("WordPress config file not found: %s", settingsFile)
+		return nil
+	}
+
+	mysqlDB := Env.GetString("MYSQL_DATABASE")
+	mysqlUser := Env.GetString("MYSQL_USER")
+	mysqlPassword := Env.GetString("MYSQL_PASSWORD")
+	siteURL := wpLocalURL()
+
+	logrus.Infof("Replacing accesses in: %s", settingsFile)
+	err := replaceLines(settingsFile, []lineReplacement{
+		{`'DB_HOST'`, `define('DB_HOST', 'db');`},
+		{`'DB_NAME'`, `define('DB_NAME', '` + mysqlDB + `');`},
+		{`'DB_USER'`, `define('DB_USER', '` + mysqlUser + `');`},
+		{`'DB_PASSWORD'`, `define('DB_PASSWORD', '` + mysqlPassword + `');`},
+		{`'WP_HOME'`, `define('WP_HOME', '` + siteURL + `');`},
+		{`'WP_SITEURL'`, `define('WP_SITEURL', '` + siteURL + `');`},
+	})
+	if err != nil {
+		return err
+	}
+
+	return wpAddDefines(settingsFile, map[string]string{"WP_HOME": siteURL, "WP_SITEURL": siteURL})
+}
+
+// wpAddDefines Adding missing constants to wp-config.php before loading wp-settings.php
+func wpAddDefines(file string, defines map[string]string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, name := range []string{"WP_HOME", "WP_SITEURL"} {
+		if !strings.Contains(string(data), "'"+name+"'") {
+			missing = append(missing, "define('"+name+"', '"+defines[name]+"');")
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(string(data), "\n")
+	pos := len(lines)
+	for i, line := range lines {
+		if strings.Contains(line, "stop editing") || strings.Contains(line, "wp-settings.php") {
+			pos = i
+			break
+		}
+	}
+	lines = slices.Insert(lines, pos, missing...)
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+}
+
+func (wordpress) ConfigFiles() []string {
+	return []string{"wp-config.php"}
+}
+
+// PostImport Replacing the production site address with the local one in the rows that contain it
+func (wordpress) PostImport(ctx context.Context) error {
+	if DBEngine() != EngineMysql {
+		return nil
+	}
+
+	prodURL, err := wpSiteURL(ctx)
+	if err != nil || len(prodURL) == 0 {
+		return err
+	}
+
+	u, err := url.Parse(prodURL)
+	if err != nil || len(u.Host) == 0 {
+		logrus.Infof("Failed to parse the site address: %s", prodURL)
+		return nil
+	}
+
+	localURL := wpLocalURL()
+	localHost := strings.TrimPrefix(localURL, "http://")
+	if u.Host == localHost {
+		return nil
+	}
+
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Database", StatusText: "Replace site address"})
+	logrus.Infof("Replace site address: %s -> %s", u.Host, localURL)
+
+	columns, err := wpTextColumns(ctx)
+	if err != nil {
+		return err
+	}
+
+	tables := make([]string, 0, len(columns))
+	for table := range columns {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+
+	sr := newHostReplace(u.Host, localURL, localHost)
+	for _, table := range tables {
+		if columns[table] == nil {
+			logrus.Infof("Table %s has no primary or unique key, the site address is not replaced", table)
+			continue
+		}
+
+		err = wpReplaceTable(ctx, sr, table, wpHostCondition(columns[table], u.Host))
+		if err != nil {
+			return fmt.Errorf("replace site address in %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// wpReplaceTable Replacing in the rows of the table that match the condition.
+// The rows are dumped as REPLACE statements and buffered before the import: the import into
+// the table being dumped could wait for the lock held by the dump (MyISAM).
+// The dump disables the foreign key checks, so replacing the rows does not cascade.
+func wpReplaceTable(ctx context.Context, sr *SearchReplace, table, where string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		cmd := []string{"mysqldump", "--user=root", "--single-transaction", "--no-tablespaces", "--no-create-info",
+			"--replace", "--skip-triggers", "--where=" + where, Env.GetString("MYSQL_DATABASE"), table}
+		env := []string{"MYSQL_PWD=" + Env.GetString("MYSQL_ROOT_PASSWORD")}
+		_ = pw.CloseWithError(execLocalDB(ctx, cmd, env, nil, pw))
+	}()
+
+	rows := &bytes.Buffer{}
+	err := sr.Copy(rows, pr)
+	_ = pr.CloseWithError(err)
+	if err != nil {
+		return err
+	}
+
+	return execLocalSQL(ctx, rows, nil)
+}
+
+// wpTextColumns text columns of the local database tables; the tables without
+// a primary or unique key are listed with nil columns, their rows cannot be replaced
+func wpTextColumns(ctx context.Context) (map[string][]string, error) {
+	query := "SELECT c.TABLE_NAME, c.COLUMN_NAME, EXISTS (SELECT 1 FROM information_schema.TABLE_CONSTRAINTS k " +
+		"WHERE k.TABLE_SCHEMA = c.TABLE_SCHEMA AND k.TABLE_NAME = c.TABLE_NAME AND k.CONSTRAINT_TYPE IN ('PRIMARY KEY', 'UNIQUE')) " +
+		"FROM information_schema.COLUMNS c JOIN information_schema.TABLES t " +
+		"ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME AND t.TABLE_TYPE = 'BASE TABLE' " +
+		"WHERE c.TABLE_SCHEMA = DATABASE() AND c.DATA_TYPE IN ('char', 'varchar', 'tinytext', 'text', 'mediumtext', 'longtext') " +
+		"ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION"
+
+	cmd := []string{"mysql", "--user=root", "--batch", "--skip-column-names", "--execute=" + query, Env.GetString("MYSQL_DATABASE")}
+	out := &bytes.Buffer{}
+	err := execLocalDB(ctx, cmd, []string{"MYSQL_PWD=" + Env.GetString("MYSQL_ROOT_PASSWORD")}, nil, out)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseTextColumns(out.String()), nil
+}
+
+// parseTextColumns table, column and key flag rows of the mysql batch output
+func parseTextColumns(out string) map[string][]string {
+	columns := make(map[string][]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(fields) != 3 {
+			continue
+		}
+
+		table := fields[0]
+		if fields[2] != "1" {
+			columns[table] = nil
+			continue
+		}
+		columns[table] = append(columns[table], fields[1])
+	}
+
+	return columns
+}
+
+// wpHostCondition condition of the rows that contain the host in one of the columns
+func wpHostCondition(columns []string, host string) string {
+	pattern := quoteSQLString("%//"+likeEscaper.Replace(host)+"%", EngineMysql)
+
+	conditions := make([]string, 0, len(columns))
+	for _, column := range columns {
+		conditions = append(conditions, "`"+column+"` LIKE "+pattern)
+	}
+
+	return strings.Join(conditions, " OR ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// wpSiteURL the siteurl option from the imported database
+func wpSiteURL(ctx context.Context) (string, error) {
+	options, err := wpOptionsTable(ctx)
+	if err != nil || len(options) == 0 {
+		return "", err
+	}
+
+	cmd := []string{"mysql", "--user=root", "--batch", "--skip-column-names",
+		"--execute=" + wpSiteURLQuery(options), Env.GetString("MYSQL_DATABASE")}
+	out := &bytes.Buffer{}
+	err = execLocalDB(ctx, cmd, []string{"MYSQL_PWD=" + Env.GetString("MYSQL_ROOT_PASSWORD")}, nil, out)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// wpOptionsTable options table of the local database, empty if not found
+func wpOptionsTable(ctx context.Context) (string, error) {
+	tables, err := localTables(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// The table prefix is set in wp-config.php, wp_ by default
+	options := "wp_options"
+	if !tables[options] {
+		options = ""
+		for table := range tables {
+			if strings.HasSuffix(table, "_options") && (len(options) == 0 || len(table) < len(options)) {
+				options = table
+			}
+		}
+	}
+	if len(options) == 0 {
+		logrus.Info("WordPress options table not found")
+	}
+
+	return options, nil
+}
+
+func wpSiteURLQuery(options string) string {
+	return "SELECT option_value FROM `" + options + "` WHERE option_name = 'siteurl'"
+}
+
+// PushReplace Replacing the local site address with the address of the server
+// that is read from the server database before it is replaced
+func (wordpress) PushReplace(ctx context.Context, c SSHClient, db *DBSettings) (*SearchReplace, error) {
+	if DBEngine() != EngineMysql {
+		return nil, nil
+	}
+
+	localURL, err := wpSiteURL(ctx)
+	if err != nil || len(localURL) == 0 {
+		return nil, err
+	}
+	options, err := wpOptionsTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.remoteQuery(db, wpSiteURLQuery(options))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the site address on the server: %w", err)
+	}
+	serverURL := strings.TrimSpace(string(out))
+
+	local, err := url.Parse(localURL)
+	if err != nil || len(local.Host) == 0 {
+		return nil, fmt.Errorf("failed to parse the local site address %q", localURL)
+	}
+	server, err := url.Parse(serverURL)
+	if err != nil || len(server.Host) == 0 {
+		return nil, fmt.Errorf("failed to determine the site address on the server, siteurl is %q", serverURL)
+	}
+	if local.Host == server.Host {
+		return nil, nil
+	}
+
+	logrus.Infof("Replace site address: %s -> %s", localURL, serverURL)
+	return newHostReplace(local.Host, server.Scheme+"://"+server.Host, server.Host), nil
+}
+
+// wpLocalURL local address of the site
+func wpLocalURL() string {
+	//goland:noinspection HttpUrlsUsage
+	return "http://" + Env.GetString("NIP_DOMAIN")
+}
+
+// AnonymizeRules the table prefix is taken from the options table, it is set in wp-config.php
+func (wordpress) AnonymizeRules(ctx context.Context) ([]AnonymizeRule, error) {
+	options, err := wpOptionsTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := "wp_"
+	if len(options) > 0 {
+		prefix = strings.TrimSuffix(options, "options")
+	}
+
+	return wpAnonymizeRules(prefix), nil
+}
+
+func wpAnonymizeRules(prefix string) []AnonymizeRule {
+	users, usermeta := prefix+"users", prefix+"usermeta"
+
+	return []AnonymizeRule{
+		{Table: users, Column: "user_email", Method: AnonymizeEmail, Where: "ID <> 1"},
+		{Table: users, Column: "user_login", Method: AnonymizeHash, Where: "ID <> 1"},
+		{Table: users, Column: "user_nicename", Method: AnonymizeHash, Where: "ID <> 1"},
+		{Table: users, Column: "display_name", Method: AnonymizeFixed, Value: "User", Where: "ID <> 1"},
+		{Table: users, Column: "user_url", Method: AnonymizeFixed, Value: "", Where: "ID <> 1"},
+		{Table: usermeta, Column: "meta_value", Method: AnonymizeFixed, Value: "",
+			Where: "user_id <> 1 AND meta_key IN ('first_name', 'last_name', 'nickname', 'description', 'billing_first_name', " +
+				"'billing_last_name', 'billing_phone', 'billing_address_1', 'billing_address_2', 'shipping_first_name', " +
+				"'shipping_last_name', 'shipping_phone', 'shipping_address_1', 'shipping_address_2')"},
+		{Table: usermeta, Column: "meta_value", Method: AnonymizeEmail, Where: "user_id <> 1 AND meta_key = 'billing_email'"},
+	}
+}
+
+// PrintInfo Display the local site address
+func (wordpress) PrintInfo() {
+	pterm.Println()
+	pterm.FgYellow.Printfln("The site address is replaced with %s", wpLocalURL())
+}
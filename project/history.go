@@ -0,0 +1,115 @@
+package project
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/local-deploy/dl/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Deploy statuses in the history
+const (
+	DeploySuccess   = "success"
+	DeployFailed    = "failed"
+	DeployCancelled = "cancelled"
+)
+
+// DeployRecord deploy in the project history
+type DeployRecord struct {
+	Time      time.Time `json:"time"`
+	Source    string    `json:"source,omitempty"`
+	Server    string    `json:"server"`
+	Catalog   string    `json:"catalog"`
+	Framework string    `json:"framework,omitempty"`
+	Database  bool      `json:"database"`
+	Files     bool      `json:"files"`
+	Tables    []string  `json:"tables,omitempty"`
+	Paths     []string  `json:"paths,omitempty"`
+	DumpSize  int64     `json:"dump_size,omitempty"`
+	// Cached the database is imported from the dump cache
+	Cached    bool          `json:"cached,omitempty"`
+	FilesSize int64         `json:"files_size,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	Status    string        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// HistoryPath deploy history of the project (~/.config/dl/history/<NETWORK_NAME>.jsonl), one record per line
+func HistoryPath() string {
+	return filepath.Join(utils.ConfigDir(), "history", Env.GetString("NETWORK_NAME")+".jsonl")
+}
+
+// AddHistory Appending the deploy record to the project history
+func AddHistory(record DeployRecord) error {
+	path := HistoryPath()
+	err := utils.CreateDirectory(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Add deploy to history: %s", path)
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// History deploys of the project, the newest first. Damaged lines are skipped.
+func History() ([]DeployRecord, error) {
+	f, err := os.Open(HistoryPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []DeployRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record DeployRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			logrus.Infof("Skip history line: %s", err)
+			continue
+		}
+		records = append(records, record)
+	}
+	slices.Reverse(records)
+
+	return records, scanner.Err()
+}
+
+// LastDatabaseDeploy the last successful deploy of the database, nil if there is none
+func LastDatabaseDeploy() (*DeployRecord, error) {
+	records, err := History()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		if record.Database && record.Status == DeploySuccess {
+			return &record, nil
+		}
+	}
+
+	return nil, nil
+}
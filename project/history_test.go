@@ -0,0 +1,40 @@
+package project
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	newTestConfigDir(t)
+	newTestEnv(t)
+	Env.Set("NETWORK_NAME", "site")
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := []DeployRecord{
+		{Time: start, Server: "prod", Database: true, Status: DeploySuccess},
+		{Time: start.Add(time.Hour), Server: "prod", Files: true, Status: DeploySuccess},
+		{Time: start.Add(2 * time.Hour), Server: "stage", Database: true, Status: DeployFailed, Error: "timeout"},
+	}
+	for _, record := range records {
+		if err := AddHistory(record); err != nil {
+			t.Fatalf("AddHistory() error = %v", err)
+		}
+	}
+
+	got, err := History()
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(got) != 3 || got[0].Server != "stage" || !got[2].Time.Equal(start) {
+		t.Errorf("History() = %v, want newest first", got)
+	}
+
+	last, err := LastDatabaseDeploy()
+	if err != nil {
+		t.Fatalf("LastDatabaseDeploy() error = %v", err)
+	}
+	if last == nil || !last.Time.Equal(start) {
+		t.Errorf("LastDatabaseDeploy() = %v, want the successful database deploy", last)
+	}
+}
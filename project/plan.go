@@ -0,0 +1,213 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/local-deploy/dl/utils"
+	"github.com/local-deploy/dl/utils/client"
+	"github.com/sirupsen/logrus"
+)
+
+// DeployPlan what the deploy would do. The plan is collected with read-only commands on the server,
+// nothing is written on the server or locally.
+type DeployPlan struct {
+	Server    string
+	Catalog   string
+	Framework string
+	// DB server database accesses, nil if the database is not deployed
+	DB *DBSettings
+	// Tables tables of the server database with the size of the data and indexes
+	Tables []TableInfo
+	// LocalDB local database replaced with the dump
+	LocalDB string
+	// Paths downloaded paths with the size on the server
+	Paths []PathInfo
+	// ConfigFiles local configuration files updated after the deploy
+	ConfigFiles []string
+	// Warnings problems found while collecting the plan
+	Warnings []string
+	// Stream the dump is imported and the archive is extracted from the SSH session (--stream)
+	Stream bool
+	// Sync files are synchronized over SFTP (--sync)
+	Sync bool
+	// Delete local files missing on the server are deleted (--delete)
+	Delete bool
+}
+
+// PlanOptions deploy flags shown in the plan
+type PlanOptions struct {
+	Database bool
+	Files    bool
+	Tables   []string
+	Override []string
+	Stream   bool
+	Sync     bool
+	Delete   bool
+}
+
+// TableInfo table of the server database
+type TableInfo struct {
+	Name string
+	Size int64
+	// Excluded only the schema of the table is dumped (EXCLUDED_TABLES)
+	Excluded bool
+	// Where only the rows matching the condition are dumped (FILTERED_TABLES)
+	Where string
+}
+
+// PathInfo path on the server and the local path overwritten by it
+type PathInfo struct {
+	Path  string
+	Local string
+	// Size on the server without EXCLUDED_FILES, -1 if unknown
+	Size int64
+	// Exists the local path exists and is overwritten
+	Exists bool
+}
+
+// NewDeployPlan collecting the plan of the deploy of the database and files
+func NewDeployPlan(t client.Transport, opts PlanOptions) *DeployPlan {
+	c := SSHClient{t}
+	config := t.Settings()
+
+	plan := &DeployPlan{
+		Server:  config.Addr,
+		Catalog: config.Catalog,
+		Stream:  opts.Stream,
+		Sync:    opts.Sync,
+		Delete:  opts.Sync && opts.Delete,
+	}
+
+	fw := GetFramework(config.FwType)
+	if fw != nil {
+		plan.Framework = fw.Title()
+	}
+
+	if opts.Database {
+		c.planDatabase(plan, opts.Tables)
+	}
+
+	if opts.Files {
+		c.planFiles(plan, fw, opts.Override)
+	}
+
+	return plan
+}
+
+func (c SSHClient) planDatabase(plan *DeployPlan, tables []string) {
+	plan.LocalDB = Env.GetString("MYSQL_DATABASE")
+	if DBEngine() == EnginePgsql {
+		plan.LocalDB = Env.GetString("POSTGRES_DB")
+	}
+
+	db, err := c.getMysqlSettings()
+	if err != nil {
+		plan.Warnings = append(plan.Warnings, fmt.Sprint(err))
+		return
+	}
+	db.setDefaultPort()
+	plan.DB = db
+
+	plan.Tables, err = c.tableSizes(db, tables)
+	if err != nil {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("failed to list tables: %s", err))
+	}
+}
+
+// tableSizes tables of the database sorted by size, only the specified tables if any
+func (c SSHClient) tableSizes(db *DBSettings, tables []string) ([]TableInfo, error) {
+	query := "SELECT table_name, data_length + index_length FROM information_schema.tables WHERE table_schema = " +
+		quoteSQLString(db.DataBase, EngineMysql) + " ORDER BY 2 DESC"
+	if DBEngine() == EnginePgsql {
+		query = "SELECT c.relname, pg_total_relation_size(c.oid) FROM pg_class c " +
+			"JOIN pg_namespace n ON n.oid = c.relnamespace WHERE c.relkind = 'r' AND n.nspname = 'public' ORDER BY 2 DESC"
+	}
+
+	logrus.Info("List tables of the database")
+	out, err := c.remoteQuery(db, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := parseTableSizes(string(out), tables, utils.CleanSlice(db.ExcludedTables))
+	for _, filter := range db.dumpFilters(tables) {
+		for i := range result {
+			if result[i].Name == filter.Table {
+				result[i].Where = filter.Where
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// parseTableSizes rows "name<TAB>size" of the query output
+func parseTableSizes(out string, tables, excluded []string) []TableInfo {
+	var result []TableInfo
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		name, size, found := strings.Cut(strings.TrimSpace(line), "\t")
+		if !found {
+			continue
+		}
+		if len(tables) > 0 && !slices.Contains(tables, name) {
+			continue
+		}
+
+		bytes, _ := strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+		result = append(result, TableInfo{
+			Name:     name,
+			Size:     bytes,
+			Excluded: len(tables) == 0 && slices.Contains(excluded, name),
+		})
+	}
+
+	return result
+}
+
+func (c SSHClient) planFiles(plan *DeployPlan, fw Framework, override []string) {
+	var paths []string
+	if fw != nil {
+		paths = fw.Paths()
+		for _, file := range fw.ConfigFiles() {
+			local := filepath.Join(backendPath(), filepath.FromSlash(file))
+			if utils.PathExists(local) {
+				plan.ConfigFiles = append(plan.ConfigFiles, local)
+			}
+		}
+	}
+	if len(override) > 0 {
+		paths = override
+	}
+
+	for _, p := range paths {
+		size, err := c.estimateFilesSize(p)()
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("failed to get the size of %s: %s", p, err))
+			size = -1
+		}
+
+		local := filepath.Join(backendPath(), filepath.FromSlash(p))
+		plan.Paths = append(plan.Paths, PathInfo{
+			Path:   p,
+			Local:  local,
+			Size:   size,
+			Exists: utils.PathExists(local),
+		})
+	}
+}
+
+// MaskPassword password replaced with asterisks, the first and the last characters are kept for long passwords
+func MaskPassword(password string) string {
+	switch {
+	case len(password) == 0:
+		return ""
+	case len(password) < 8:
+		return strings.Repeat("*", len(password))
+	default:
+		return password[:1] + strings.Repeat("*", len(password)-2) + password[len(password)-1:]
+	}
+}
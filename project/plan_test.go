@@ -0,0 +1,58 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTableSizes(t *testing.T) {
+	out := "b_event_log\t2048\nb_user\t1024\nb_search_content\t512\n"
+
+	tests := []struct {
+		name     string
+		tables   []string
+		excluded []string
+		want     []TableInfo
+	}{
+		{
+			name:     "excluded tables",
+			excluded: []string{"b_event_log"},
+			want: []TableInfo{
+				{Name: "b_event_log", Size: 2048, Excluded: true},
+				{Name: "b_user", Size: 1024},
+				{Name: "b_search_content", Size: 512},
+			},
+		},
+		{
+			name:     "only specified tables",
+			tables:   []string{"b_user", "b_event_log"},
+			excluded: []string{"b_event_log"},
+			want: []TableInfo{
+				{Name: "b_event_log", Size: 2048},
+				{Name: "b_user", Size: 1024},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTableSizes(out, tt.tables, tt.excluded); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTableSizes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskPassword(t *testing.T) {
+	tests := []struct {
+		password, want string
+	}{
+		{"", ""},
+		{"secret", "******"},
+		{"long-password", "l***********d"},
+	}
+	for _, tt := range tests {
+		if got := MaskPassword(tt.password); got != tt.want {
+			t.Errorf("MaskPassword(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/local-deploy/dl/utils"
+	"github.com/local-deploy/dl/utils/client"
+	"github.com/sirupsen/logrus"
+)
+
+// errNoSpace not enough free space on the server
+var errNoSpace = errors.New("not enough free space on the server")
+
+// checkRemoteSpace Comparing the estimated size with the free space in CATALOG_SRV.
+// Estimation errors are not fatal: the check is skipped if the size cannot be determined.
+func (c SSHClient) checkRemoteSpace(what string, estimate func() (int64, error)) error {
+	required, err := estimate()
+	if err != nil {
+		logrus.Infof("Failed to estimate the size of the %s, the free space check is skipped: %s", what, err)
+		return nil
+	}
+
+	free, err := c.remoteFreeSpace()
+	if err != nil {
+		logrus.Infof("Failed to get free space on the server, the check is skipped: %s", err)
+		return nil
+	}
+
+	logrus.Infof("Estimated size of the %s: %s, free space on the server: %s", what,
+		utils.HumanSize(float64(required)), utils.HumanSize(float64(free)))
+	if required >= free {
+		return fmt.Errorf("%w in %s: the %s requires up to %s, available %s (use --stream to skip creating files on the server)",
+			errNoSpace, c.Settings().Catalog, what, utils.HumanSize(float64(required)), utils.HumanSize(float64(free)))
+	}
+
+	return nil
+}
+
+// CheckDeploySpace Comparing the sum of the estimated sizes of the database dump and the files archive
+// with the free space in CATALOG_SRV: both are created on the server at the same time.
+// If the database accesses cannot be determined, the check is skipped, the error is reported by the dump.
+func CheckDeploySpace(t client.Transport, tables, override []string) error {
+	c := SSHClient{t}
+	db, err := c.getMysqlSettings()
+	if err != nil {
+		logrus.Infof("Failed to get database accesses, the free space check is skipped: %s", err)
+		return nil
+	}
+	db.setDefaultPort()
+
+	return c.checkRemoteSpace("database dump and files archive", func() (int64, error) {
+		size, err := c.estimateDumpSize(db, tables)()
+		if err != nil {
+			return 0, err
+		}
+
+		if path := c.filesPath(override); len(path) > 0 {
+			files, err := c.estimateFilesSize(path)()
+			if err != nil {
+				return 0, err
+			}
+			size += files
+		}
+
+		return size, nil
+	})
+}
+
+// remoteFreeSpace free space in bytes on the partition of CATALOG_SRV
+func (c SSHClient) remoteFreeSpace() (int64, error) {
+	dfCmd := "df -Pk " + utils.ShellQuote(c.Settings().Catalog) + " | tail -n 1 | awk '{print $4}'"
+	logrus.Infof("Run command: %s", dfCmd)
+	out, err := c.Run(dfCmd)
+	if err != nil {
+		return 0, err
+	}
+
+	return parseSize(out, 1024)
+}
+
+// estimateDumpSize size of the table data in the database (data_length without indexes for MySQL).
+// It is not an upper bound of the dump: the dump is compressed and usually smaller, but the text
+// of the INSERT statements may be larger than the binary data of the tables.
+func (c SSHClient) estimateDumpSize(db *DBSettings, tables []string) func() (int64, error) {
+	return func() (int64, error) {
+		query := mysqlSizeQuery(db, tables)
+		if DBEngine() == EnginePgsql {
+			query = pgSizeQuery(db, tables)
+		}
+
+		logrus.Info("Estimate the database size")
+		out, err := c.remoteQuery(db, query)
+		if err != nil {
+			return 0, err
+		}
+
+		return parseSize(out, 1)
+	}
+}
+
+// remoteQuery Running the query in the server database, columns of the result rows are separated by tabs
+func (c SSHClient) remoteQuery(db *DBSettings, query string) ([]byte, error) {
+	var queryCmd string
+	if DBEngine() == EnginePgsql {
+		queryCmd = strings.Join([]string{"PGPASSWORD=" + strconv.Quote(db.Password), "psql",
+			"--host=" + db.Host, "--port=" + db.Port, "--username=" + db.Login, "--no-align", "--tuples-only",
+			"--field-separator=" + utils.ShellQuote("\t"), "--command=" + utils.ShellQuote(query), db.DataBase}, " ")
+	} else {
+		queryCmd = strings.Join([]string{"mysql", "--host=" + db.Host, "--port=" + db.Port, "--user=" + db.Login,
+			"--password=" + strconv.Quote(db.Password), "--batch", "--skip-column-names",
+			"--execute=" + utils.ShellQuote(query), db.DataBase}, " ")
+	}
+
+	out, err := c.Run(queryCmd)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return out, nil
+}
+
+func mysqlSizeQuery(db *DBSettings, tables []string) string {
+	query := "SELECT COALESCE(SUM(data_length), 0) FROM information_schema.tables WHERE table_schema = " + quoteSQLString(db.DataBase, EngineMysql)
+	if len(tables) > 0 {
+		return query + " AND table_name IN (" + sqlList(tables, EngineMysql) + ")"
+	}
+	if excluded := utils.CleanSlice(db.ExcludedTables); len(excluded) > 0 {
+		return query + " AND table_name NOT IN (" + sqlList(excluded, EngineMysql) + ")"
+	}
+
+	return query
+}
+
+func pgSizeQuery(_ *DBSettings, tables []string) string {
+	if len(tables) > 0 {
+		return "SELECT COALESCE(SUM(pg_table_size(c.oid)), 0) FROM pg_class c WHERE c.relkind = 'r' AND c.relname IN (" + sqlList(tables, EnginePgsql) + ")"
+	}
+
+	return "SELECT pg_database_size(current_database())"
+}
+
+// sqlList list of string literals separated by commas
+func sqlList(values []string, engine string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quoteSQLString(strings.TrimSpace(v), engine)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// estimateFilesSize size of the paths on the server without excluded files
+func (c SSHClient) estimateFilesSize(path string) func() (int64, error) {
+	return func() (int64, error) {
+		duCmd := strings.Join([]string{"cd", c.Settings().Catalog, "&&", "du", "-skLc", FormatIgnoredPath(), path, "| tail -n 1 | awk '{print $1}'"}, " ")
+		logrus.Infof("Run command: %s", duCmd)
+		out, err := c.Run(duCmd)
+		if err != nil {
+			return 0, err
+		}
+
+		return parseSize(out, 1024)
+	}
+}
+
+// parseSize number from the command output multiplied by the unit size
+func parseSize(out []byte, unit int64) (int64, error) {
+	size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected output: %q", strings.TrimSpace(string(out)))
+	}
+
+	return size * unit, nil
+}
@@ -0,0 +1,186 @@
+package project
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/utils"
+	"github.com/local-deploy/dl/utils/client"
+	"github.com/sirupsen/logrus"
+)
+
+// PushDB Uploading the local database to the server: the server database is backed up to ~/dl-backup,
+// the local dump is uploaded to a temporary directory outside the site root, imported into the server database
+// and deleted. Returns the path of the backup on the server.
+func PushDB(ctx context.Context, t client.Transport) (string, error) {
+	c := &SSHClient{t}
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Database", Status: progress.Working})
+
+	db, err := c.getMysqlSettings()
+	if err != nil {
+		return "", err
+	}
+	db.setDefaultPort()
+
+	var sr *SearchReplace
+	if fw, ok := GetFramework(c.Settings().FwType).(PushReplacer); ok {
+		sr, err = fw.PushReplace(ctx, *c, db)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	name := "local.sql.gz"
+	if DBEngine() == EnginePgsql {
+		name = "local.dump"
+	}
+	localPath := filepath.Join(Env.GetString("PWD"), name)
+
+	w.Event(progress.Event{ID: "Database", StatusText: "Export local database"})
+	err = exportLocalDB(ctx, localPath, sr)
+	defer func() {
+		logrus.Infof("Delete dump: %s", localPath)
+		_ = os.Remove(localPath)
+	}()
+	if err != nil {
+		return "", err
+	}
+
+	// The dump is not placed in CATALOG_SRV: it could be downloaded from the site while it is imported
+	out, err := c.Run("mktemp -d")
+	if err != nil {
+		return "", fmt.Errorf("failed to create a temporary directory on the server: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	tmpDir := strings.TrimSpace(string(out))
+	defer func() {
+		_, _ = c.Run("rm -rf " + utils.ShellQuote(tmpDir))
+	}()
+	serverPath := path.Join(tmpDir, name)
+
+	w.Event(progress.Event{ID: "Database", StatusText: "Upload dump"})
+	logrus.Infof("Upload dump: %s", serverPath)
+	err = c.Upload(ctx, localPath, serverPath)
+	if err != nil {
+		return "", err
+	}
+
+	w.Event(progress.Event{ID: "Database", StatusText: "Back up server database"})
+	backup, err := c.backupDB(ctx, db)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up the server database: %w", err)
+	}
+
+	w.Event(progress.Event{ID: "Database", StatusText: "Import database on the server"})
+	importCmd := c.remoteImportCommand(db, serverPath)
+	logrus.Infof("Run command: %s", importCmd)
+	out, err = c.Run(importCmd)
+	if err != nil {
+		return backup, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	w.Event(progress.Event{ID: "Database", Status: progress.Done})
+	return backup, nil
+}
+
+// backupDB Dump of the whole server database (EXCLUDED_TABLES and FILTERED_TABLES are not applied)
+// into ~/dl-backup on the server, returns the path of the dump
+func (c SSHClient) backupDB(ctx context.Context, db *DBSettings) (string, error) {
+	full := *db
+	full.ExcludedTables, full.FilteredTables = nil, nil
+
+	dumpCmd, err := c.dumpCommand(&full, nil)
+	if err != nil {
+		return "", err
+	}
+
+	name := db.DataBase + "-" + time.Now().Format("20060102-150405") + strings.TrimPrefix(DumpFileName(), "production")
+	backupCmd := `mkdir -p "$HOME/dl-backup" && ` + dumpCmd + ` > "$HOME/dl-backup/"` + utils.ShellQuote(name)
+	logrus.Infof("Run command: %s", backupCmd)
+	err = c.runContext(ctx, backupCmd)
+	if err != nil {
+		return "", err
+	}
+
+	return "~/dl-backup/" + name, nil
+}
+
+// remoteImportCommand Command importing the dump file on the server into the server database
+func (c SSHClient) remoteImportCommand(db *DBSettings, dumpPath string) string {
+	if DBEngine() == EnginePgsql {
+		return strings.Join([]string{
+			"PGPASSWORD=" + strconv.Quote(db.Password),
+			"pg_restore",
+			"--host=" + db.Host,
+			"--port=" + db.Port,
+			"--username=" + db.Login,
+			"--dbname=" + db.DataBase,
+			"--no-owner",
+			"--no-acl",
+			"--clean",
+			"--if-exists",
+			utils.ShellQuote(dumpPath),
+		}, " ")
+	}
+
+	return pipefail(
+		"gunzip", "<", utils.ShellQuote(dumpPath),
+		"|",
+		"mysql",
+		"--host="+db.Host,
+		"--port="+db.Port,
+		"--user="+db.Login,
+		"--password="+strconv.Quote(db.Password),
+		db.DataBase,
+	)
+}
+
+// PushFiles Uploading the local paths to CATALOG_SRV, the archive is streamed through the SSH session.
+// EXCLUDED_FILES and the configuration files of the framework are not uploaded.
+func PushFiles(ctx context.Context, client *client.Client, paths []string) error {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Files", Status: progress.Working})
+
+	excluded := ExcludedFiles()
+	var configFiles []string
+	if fw := GetFramework(client.Config.FwType); fw != nil {
+		configFiles = fw.ConfigFiles()
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(pw)
+		err := utils.CreateTar(gz, backendPath(), paths, func(rel string) bool {
+			return slices.Contains(configFiles, rel) || IsExcludedFile(rel, excluded)
+		})
+		if err == nil {
+			err = gz.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	r := utils.NewProgressReader(pr, 0, func(read, _ int64) {
+		w.Event(progress.Event{ID: "Files", StatusText: "Upload files: " + utils.FormatProgress(read, 0)})
+	})
+
+	tarCmd := strings.Join([]string{"cd", client.Config.Catalog, "&&", "tar", "-xzf", "-"}, " ")
+	logrus.Infof("Upload paths %s: %s", strings.Join(paths, " "), tarCmd)
+	out, err := client.RunWithStdin(tarCmd, r)
+	_ = pr.CloseWithError(err)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	w.Event(progress.Event{ID: "Files", Status: progress.Done})
+	return nil
+}
@@ -0,0 +1,109 @@
+package project
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runContext Running the long command on the server, the command is terminated if the context is cancelled.
+// The command writes to files, not to the session, so closing the session does not stop it, and servers
+// may ignore signals: the process group of the command is killed by the PID printed before it.
+func (c SSHClient) runContext(ctx context.Context, cmd string) error {
+	stream, err := c.Stream("echo $$; " + cmd)
+	if err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(stream)
+	line, _ := r.ReadString('\n')
+	pid := strings.TrimSpace(line)
+
+	stop := context.AfterFunc(ctx, func() {
+		c.killRemote(pid)
+		_ = stream.Close()
+	})
+	defer stop()
+
+	_, err = io.Copy(io.Discard, r)
+	if err == nil {
+		err = stream.Wait()
+	}
+	_ = stream.Close()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// killRemote Terminating the remote shell with the PID and its children.
+// The shell of the session is the leader of its process group, the group is killed, the process itself if it is not.
+func (c SSHClient) killRemote(pid string) {
+	if _, err := strconv.Atoi(pid); err != nil {
+		logrus.Infof("Remote command PID is unknown: %q", pid)
+		return
+	}
+
+	killCmd := "kill -TERM -" + pid + " 2>/dev/null || kill -TERM " + pid
+	logrus.Infof("Run command: %s", killCmd)
+	out, err := c.Run(killCmd)
+	if err != nil {
+		logrus.Infof("Failed to terminate the remote command: %s: %s", err, strings.TrimSpace(string(out)))
+	}
+}
+
+// downloadAttempts attempts to download the dump or the archive
+const downloadAttempts = 3
+
+// downloadRetryDelay pause before the next attempt, it grows with each attempt
+var downloadRetryDelay = time.Second
+
+// download Downloading the file from the server. A failed download is retried while the file
+// is still on the server, the SFTP download is resumed from the downloaded part.
+func (c SSHClient) download(ctx context.Context, id, serverPath, localPath string) error {
+	var err error
+	for attempt := 1; attempt <= downloadAttempts; attempt++ {
+		if attempt > 1 {
+			logrus.Infof("Download failed, attempt %d of %d: %s", attempt, downloadAttempts, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * downloadRetryDelay):
+			}
+		}
+
+		err = c.Download(ctx, id, serverPath, localPath)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+// removeArtifacts Deleting the temporary file on the server and its local copy with partial downloads,
+// it is called when the deploy is finished, failed or cancelled
+func (c SSHClient) removeArtifacts(serverPath, localPath string) {
+	logrus.Infof("Delete file on the server: %s", serverPath)
+	err := c.Remove(serverPath)
+	if err != nil && !os.IsNotExist(err) {
+		logrus.Infof("Failed to delete file on the server %s: %s", serverPath, err)
+	}
+
+	parts, _ := filepath.Glob(localPath + ".*.part")
+	for _, path := range append(parts, localPath) {
+		err = os.Remove(path)
+		if err == nil {
+			logrus.Infof("Delete local file: %s", path)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package project
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/local-deploy/dl/utils/client"
+)
+
+// localTransport running the "remote" commands in the local shell, the shell is the leader
+// of its process group as the shell of an SSH session
+type localTransport struct {
+	client.Transport
+}
+
+func (localTransport) Run(cmd string) ([]byte, error) {
+	return exec.Command("sh", "-c", cmd).CombinedOutput()
+}
+
+func (localTransport) Stream(cmd string) (client.RemoteStream, error) {
+	c := exec.Command("sh", "-c", cmd)
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Stderr = &bytes.Buffer{}
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = c.Start(); err != nil {
+		return nil, err
+	}
+
+	return &localStream{Reader: stdout, cmd: c}, nil
+}
+
+type localStream struct {
+	io.Reader
+	cmd *exec.Cmd
+}
+
+func (s *localStream) Wait() error {
+	return s.cmd.Wait()
+}
+
+// Close does not terminate the command, as on a server ignoring signals
+func (s *localStream) Close() error {
+	return nil
+}
+
+func TestRunContextCancel(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "done")
+	c := SSHClient{localTransport{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	// The child writes to a file, not to the session, and is not stopped by closing it
+	err := c.runContext(ctx, "sh -c 'sleep 1; touch "+marker+"' > /dev/null")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("runContext() error = %v, want context.Canceled", err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	if _, err = os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("remote command is not terminated after cancel")
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "done")
+	c := SSHClient{localTransport{}}
+
+	if err := c.runContext(context.Background(), "touch "+marker); err != nil {
+		t.Fatalf("runContext() error = %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("remote command is not run: %v", err)
+	}
+	if err := c.runContext(context.Background(), "exit 3"); err == nil {
+		t.Error("runContext() expected error of the failed command")
+	}
+}
+
+// flakyTransport failing the first downloads
+type flakyTransport struct {
+	client.Transport
+	failures int
+	calls    *int
+}
+
+func (t flakyTransport) Download(_ context.Context, _, _, _ string) error {
+	*t.calls++
+	if *t.calls <= t.failures {
+		return errors.New("connection lost")
+	}
+
+	return nil
+}
+
+func TestDownloadRetry(t *testing.T) {
+	delay := downloadRetryDelay
+	downloadRetryDelay = 0
+	t.Cleanup(func() { downloadRetryDelay = delay })
+
+	calls := 0
+	c := SSHClient{flakyTransport{failures: 1, calls: &calls}}
+	if err := c.download(context.Background(), "Database", "dump.sql", "dump.sql"); err != nil {
+		t.Errorf("download() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("download() calls = %d, want 2", calls)
+	}
+
+	calls = 0
+	c = SSHClient{flakyTransport{failures: downloadAttempts, calls: &calls}}
+	if err := c.download(context.Background(), "Database", "dump.sql", "dump.sql"); err == nil {
+		t.Error("download() expected error after all attempts")
+	}
+	if calls != downloadAttempts {
+		t.Errorf("download() calls = %d, want %d", calls, downloadAttempts)
+	}
+}
@@ -0,0 +1,177 @@
+package project
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	serializedSQLString = regexp.MustCompile(`s:(\d+):\\"`)
+	serializedString    = regexp.MustCompile(`s:(\d+):"`)
+)
+
+// SearchReplace Replacing strings in a MySQL dump, taking into account PHP serialized data:
+// the length prefixes of the changed serialized strings are recalculated
+type SearchReplace struct {
+	replace func(string) string
+}
+
+// NewSearchReplace pairs of old and new strings, as in strings.NewReplacer
+func NewSearchReplace(oldnew ...string) *SearchReplace {
+	return &SearchReplace{replace: strings.NewReplacer(oldnew...).Replace}
+}
+
+// newHostReplace replacing the addresses of the host: http and https addresses with newURL,
+// protocol-relative addresses with //newHost. The host must end after the match,
+// so //example.com.cdn.net and //example.com-old are not replaced for example.com.
+func newHostReplace(host, newURL, newHost string) *SearchReplace {
+	re := regexp.MustCompile(`(?:(https?:))?//` + regexp.QuoteMeta(host))
+
+	return &SearchReplace{replace: func(text string) string {
+		var out strings.Builder
+		plain := 0
+		for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+			if m[1] < len(text) && isHostChar(text[m[1]]) {
+				continue
+			}
+
+			out.WriteString(text[plain:m[0]])
+			if m[2] >= 0 {
+				out.WriteString(newURL)
+			} else {
+				out.WriteString("//" + newHost)
+			}
+			plain = m[1]
+		}
+		out.WriteString(text[plain:])
+
+		return out.String()
+	}}
+}
+
+// isHostChar character that may continue a host name
+func isHostChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-'
+}
+
+// Copy Copying the dump from src to dst line by line with replacement
+func (s *SearchReplace) Copy(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReaderSize(src, 1024*1024)
+	for {
+		line, err := r.ReadString('\n')
+		if len(line) > 0 {
+			if _, werr := io.WriteString(dst, s.Replace(line, true)); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Replace Replacing in the text; escaped is true for the text of SQL statements,
+// where string values are escaped by mysqldump
+func (s *SearchReplace) Replace(text string, escaped bool) string {
+	re, quote := serializedString, `"`
+	if escaped {
+		re, quote = serializedSQLString, `\"`
+	}
+
+	var out strings.Builder
+	plain, pos := 0, 0
+	for pos < len(text) {
+		m := re.FindStringSubmatchIndex(text[pos:])
+		if m == nil {
+			break
+		}
+
+		start, contentStart := pos+m[0], pos+m[1]
+		length, _ := strconv.Atoi(text[pos+m[2] : pos+m[3]])
+		contentEnd, ok := serializedEnd(text, contentStart, length, escaped)
+		if !ok || !strings.HasPrefix(text[contentEnd:], quote+";") {
+			pos = start + 1
+			continue
+		}
+
+		content := text[contentStart:contentEnd]
+		if escaped {
+			content = unescapeSQL(content)
+		}
+		replaced := s.Replace(content, false)
+
+		out.WriteString(s.replace(text[plain:start]))
+		if replaced == content {
+			out.WriteString(text[start:contentEnd])
+		} else {
+			length = len(replaced)
+			if escaped {
+				replaced = escapeSQL(replaced)
+			}
+			out.WriteString("s:" + strconv.Itoa(length) + ":" + quote + replaced)
+		}
+
+		plain, pos = contentEnd, contentEnd
+	}
+	out.WriteString(s.replace(text[plain:]))
+
+	return out.String()
+}
+
+// serializedEnd position of the end of the serialized string with the length in bytes
+func serializedEnd(text string, start, length int, escaped bool) (int, bool) {
+	if !escaped {
+		end := start + length
+		return end, end <= len(text)
+	}
+
+	i := start
+	for n := 0; n < length; n++ {
+		if i >= len(text) {
+			return 0, false
+		}
+		if text[i] == '\\' {
+			i++
+		}
+		i++
+	}
+
+	return i, i <= len(text)
+}
+
+var sqlUnescape = map[byte]byte{'0': 0, 'n': '\n', 'r': '\r', 'Z': 0x1a, 't': '\t', 'b': '\b'}
+
+// unescapeSQL string value from the mysqldump escaped form
+func unescapeSQL(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if c, ok := sqlUnescape[s[i]]; ok {
+				b.WriteByte(c)
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+var sqlEscaper = strings.NewReplacer(`\`, `\\`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`, `'`, `\'`, `"`, `\"`)
+
+// escapeSQL string value in the form escaped by mysqldump
+func escapeSQL(s string) string {
+	return sqlEscaper.Replace(s)
+}
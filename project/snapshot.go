@@ -0,0 +1,181 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// snapshotExt extension of the snapshot files
+const snapshotExt = ".sql.zst"
+
+// snapshotName the names starting with a dot are reserved for the snapshots being created
+var snapshotName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// snapshotTempPattern snapshot being created, it is renamed when the export is complete
+const snapshotTempPattern = ".create-*" + snapshotExt
+
+// Snapshot local database snapshot
+type Snapshot struct {
+	Name    string
+	Size    int64
+	Created time.Time
+}
+
+// SnapshotDir directory of the project snapshots (~/.config/dl/snapshots/<NETWORK_NAME>)
+func SnapshotDir() string {
+	return filepath.Join(utils.ConfigDir(), "snapshots", Env.GetString("NETWORK_NAME"))
+}
+
+// SnapshotPath path to the snapshot file
+func SnapshotPath(name string) (string, error) {
+	if !snapshotName.MatchString(name) {
+		return "", fmt.Errorf("invalid snapshot name %q, only letters, digits, dots, dashes and underscores are allowed, "+
+			"the name must not start with a dot", name)
+	}
+
+	return filepath.Join(SnapshotDir(), name+snapshotExt), nil
+}
+
+// CreateSnapshot Saving a logical dump of the local database as a snapshot
+func CreateSnapshot(ctx context.Context, name string) error {
+	path, err := SnapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	if utils.PathExists(path) {
+		return fmt.Errorf("snapshot %s already exists", name)
+	}
+
+	err = utils.CreateDirectory(SnapshotDir())
+	if err != nil {
+		return err
+	}
+
+	// Files left by an interrupted export
+	stale, _ := filepath.Glob(filepath.Join(SnapshotDir(), snapshotTempPattern))
+	for _, file := range stale {
+		logrus.Infof("Delete incomplete snapshot: %s", file)
+		_ = os.Remove(file)
+	}
+
+	// The dump is written to a temporary file, an interrupted export does not leave a truncated snapshot
+	tmp, err := os.CreateTemp(SnapshotDir(), snapshotTempPattern)
+	if err != nil {
+		return err
+	}
+	_ = tmp.Close()
+
+	logrus.Infof("Create snapshot: %s", path)
+	err = ExportFile(ctx, tmp.Name())
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// RestoreSnapshot Recreating the local database from the snapshot
+func RestoreSnapshot(ctx context.Context, name string) error {
+	path, err := SnapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	if !utils.PathExists(path) {
+		return fmt.Errorf("snapshot %s not found", name)
+	}
+
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: "Database", Status: progress.Working, StatusText: "Clear database"})
+
+	err = clearLocalDB(ctx)
+	if err != nil {
+		w.Event(progress.ErrorMessageEvent("Database", fmt.Sprintf("Failed to clear database: %s", err)))
+		return err
+	}
+
+	logrus.Infof("Restore snapshot: %s", path)
+	return ImportFile(ctx, path)
+}
+
+// DeleteSnapshot Deleting the snapshot file
+func DeleteSnapshot(name string) error {
+	path, err := SnapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	if !utils.PathExists(path) {
+		return fmt.Errorf("snapshot %s not found", name)
+	}
+
+	logrus.Infof("Delete snapshot: %s", path)
+	return os.Remove(path)
+}
+
+// Snapshots list of the project snapshots, newest first
+func Snapshots() ([]Snapshot, error) {
+	entries, err := os.ReadDir(SnapshotDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), snapshotExt) || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		snapshots = append(snapshots, Snapshot{
+			Name:    strings.TrimSuffix(entry.Name(), snapshotExt),
+			Size:    info.Size(),
+			Created: info.ModTime(),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+
+	return snapshots, nil
+}
+
+// clearLocalDB Deleting all data of the local database before restoring
+func clearLocalDB(ctx context.Context) error {
+	if DBEngine() == EnginePgsql {
+		pgDB := Env.GetString("POSTGRES_DB")
+		pgUser := Env.GetString("POSTGRES_USER")
+		pgPassword := Env.GetString("POSTGRES_PASSWORD")
+		sql := "DROP SCHEMA public CASCADE; CREATE SCHEMA public;"
+
+		return execLocalDB(ctx, []string{"psql", "--quiet", "--username=" + pgUser, "--dbname=" + pgDB, "--command=" + sql},
+			[]string{"PGPASSWORD=" + pgPassword}, nil, nil)
+	}
+
+	mysqlDB := Env.GetString("MYSQL_DATABASE")
+	mysqlRootPassword := Env.GetString("MYSQL_ROOT_PASSWORD")
+	sql := fmt.Sprintf("DROP DATABASE IF EXISTS `%[1]s`; CREATE DATABASE `%[1]s`;", mysqlDB)
+
+	return execLocalDB(ctx, []string{"mysql", "--user=root", "--execute=" + sql}, []string{"MYSQL_PWD=" + mysqlRootPassword}, nil, nil)
+}
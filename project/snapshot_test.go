@@ -0,0 +1,20 @@
+package project
+
+import "testing"
+
+func TestSnapshotPath(t *testing.T) {
+	newTestEnv(t)
+
+	for _, name := range []string{"before-migration", "v1.2_test"} {
+		if _, err := SnapshotPath(name); err != nil {
+			t.Errorf("SnapshotPath(%q) error = %v", name, err)
+		}
+	}
+
+	// The names starting with a dot are the snapshots being created
+	for _, name := range []string{".create-1", "../db", "a b", ""} {
+		if _, err := SnapshotPath(name); err == nil {
+			t.Errorf("SnapshotPath(%q) expected error", name)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package project
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sourcePrefix prefix of the named deploy source variables, for example SOURCE_STAGE_SERVER
+const sourcePrefix = "SOURCE_"
+
+// currentSource name of the source selected with UseSource
+var currentSource string
+
+// serverVariables variables of the default server that are not inherited by a named source:
+// the server, the catalog and the database accesses are set for each source or determined on its server.
+// The connection settings (SSH_KEY, SSH_HOST_KEY_POLICY, etc.) are inherited.
+var serverVariables = []string{
+	"SERVER", "TELEPORT", "USER_SRV", "PORT_SRV", "CATALOG_SRV",
+	"MYSQL_HOST_SRV", "MYSQL_PORT_SRV", "MYSQL_DATABASE_SRV", "MYSQL_LOGIN_SRV", "MYSQL_PASSWORD_SRV",
+	"POSTGRES_HOST_SRV", "POSTGRES_PORT_SRV", "POSTGRES_DATABASE_SRV", "POSTGRES_LOGIN_SRV", "POSTGRES_PASSWORD_SRV",
+}
+
+// Sources names of the deploy sources defined in the project variables
+func Sources() []string {
+	var sources []string
+	for _, key := range Env.AllKeys() {
+		name, _, ok := splitSourceKey(key)
+		if ok && !slices.Contains(sources, name) {
+			sources = append(sources, name)
+		}
+	}
+	slices.Sort(sources)
+
+	return sources
+}
+
+// UseSource Overriding the server variables with the variables of the named source:
+// SOURCE_<NAME>_SERVER replaces SERVER, SOURCE_<NAME>_MYSQL_PASSWORD_SRV replaces MYSQL_PASSWORD_SRV, etc.
+// The server, the catalog and the database accesses of the default server are not inherited, so that
+// the production database is never used by mistake: the source must set its server (or Teleport node)
+// and catalog, the database accesses are determined on the source server if they are not set.
+// If the name is empty, the DEPLOY_SOURCE variable is used, without it the default variables are kept.
+func UseSource(name string) error {
+	if len(name) == 0 {
+		name = Env.GetString("DEPLOY_SOURCE")
+	}
+	if len(name) == 0 {
+		return nil
+	}
+
+	name = strings.ToLower(name)
+	variables := make(map[string]string)
+	for _, key := range Env.AllKeys() {
+		source, variable, ok := splitSourceKey(key)
+		if ok && source == name {
+			variables[variable] = Env.GetString(key)
+		}
+	}
+
+	if len(variables) == 0 {
+		return fmt.Errorf("deploy source %q is not defined, available sources: %s", name, strings.Join(Sources(), ", "))
+	}
+
+	prefix := sourcePrefix + strings.ToUpper(name) + "_"
+	if len(variables["SERVER"]) == 0 && len(variables["TELEPORT"]) == 0 {
+		return fmt.Errorf("deploy source %q: %sSERVER or %sTELEPORT is required", name, prefix, prefix)
+	}
+	if len(variables["CATALOG_SRV"]) == 0 {
+		return fmt.Errorf("deploy source %q: %sCATALOG_SRV is required", name, prefix)
+	}
+
+	for _, variable := range serverVariables {
+		Env.Set(variable, "")
+	}
+	for variable, value := range variables {
+		Env.Set(variable, value)
+	}
+
+	currentSource = name
+	logrus.Infof("Deploy source is used: %s", name)
+	return nil
+}
+
+// CurrentSource name of the selected deploy source, empty for the default server
+func CurrentSource() string {
+	return currentSource
+}
+
+// PushAllowed uploading to the server is allowed with ALLOW_PUSH=true, for a named source
+// SOURCE_<NAME>_ALLOW_PUSH=true is required, the variable of the default server is not inherited
+func PushAllowed() bool {
+	if len(currentSource) > 0 {
+		return Env.GetBool(sourcePrefix + strings.ToUpper(currentSource) + "_ALLOW_PUSH")
+	}
+
+	return Env.GetBool("ALLOW_PUSH")
+}
+
+// splitSourceKey splitting the variable into the source name and the overridden variable
+func splitSourceKey(key string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(strings.ToUpper(key), sourcePrefix)
+	if !ok {
+		return "", "", false
+	}
+
+	name, variable, ok := strings.Cut(rest, "_")
+	if !ok || len(name) == 0 || len(variable) == 0 {
+		return "", "", false
+	}
+
+	return strings.ToLower(name), variable, true
+}
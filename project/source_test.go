@@ -0,0 +1,55 @@
+package project
+
+import (
+	"testing"
+)
+
+func TestUseSource(t *testing.T) {
+	env := newTestEnv(t)
+	t.Cleanup(func() {
+		currentSource = ""
+	})
+	env.Set("SERVER", "prod.example.com")
+	env.Set("CATALOG_SRV", "/var/www/prod")
+	env.Set("MYSQL_LOGIN_SRV", "prod")
+	env.Set("SSH_KEY", "id_deploy")
+	env.Set("SOURCE_STAGE_SERVER", "stage.example.com")
+	env.Set("SOURCE_STAGE_CATALOG_SRV", "/var/www/stage")
+	env.Set("SOURCE_STAGE_MYSQL_PASSWORD_SRV", "secret")
+	env.Set("SOURCE_DEV_SERVER", "dev.example.com")
+
+	if got := Sources(); len(got) != 2 || got[0] != "dev" || got[1] != "stage" {
+		t.Errorf("Sources() = %v, want [dev stage]", got)
+	}
+
+	if err := UseSource("missing"); err == nil {
+		t.Error("UseSource() expected error for undefined source")
+	}
+
+	if err := UseSource("dev"); err == nil {
+		t.Error("UseSource() expected error for source without catalog")
+	}
+	if got := env.GetString("SERVER"); got != "prod.example.com" {
+		t.Errorf("SERVER = %s, the variables must not change on error", got)
+	}
+
+	if err := UseSource("stage"); err != nil {
+		t.Fatalf("UseSource() error = %v", err)
+	}
+
+	tests := []struct {
+		variable string
+		want     string
+	}{
+		{variable: "SERVER", want: "stage.example.com"},
+		{variable: "CATALOG_SRV", want: "/var/www/stage"},
+		{variable: "MYSQL_PASSWORD_SRV", want: "secret"},
+		{variable: "MYSQL_LOGIN_SRV", want: ""},
+		{variable: "SSH_KEY", want: "id_deploy"},
+	}
+	for _, tt := range tests {
+		if got := env.GetString(tt.variable); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.variable, got, tt.want)
+		}
+	}
+}
@@ -2,16 +2,15 @@ package project
 
 import "github.com/local-deploy/dl/utils/client"
 
-// CallMethod to dynamically determine the function name
-type CallMethod struct{}
-
-// SSHClient client ssh
+// SSHClient connection to the server, SSH or Teleport
 type SSHClient struct {
-	*client.Client
+	client.Transport
 }
 
 // DBSettings database settings
 type DBSettings struct {
 	Host, DataBase, Login, Password, Port string
 	ExcludedTables                        []string
+	// FilteredTables only the rows matching the condition are dumped (FILTERED_TABLES)
+	FilteredTables []TableFilter
 }
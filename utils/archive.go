@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CreateTar writing the paths relative to the root directory into a tar archive.
+// skip is called with the relative slash separated path, skipped directories are not walked.
+func CreateTar(w io.Writer, root string, paths []string, skip func(rel string) bool) error {
+	tw := tar.NewWriter(w)
+	for _, p := range paths {
+		err := filepath.Walk(filepath.Join(root, filepath.FromSlash(p)), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+
+			if skip != nil && skip(rel) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			return addTarEntry(tw, path, rel, info)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+func addTarEntry(tw *tar.Writer, path, rel string, info os.FileInfo) error {
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = rel
+	if info.IsDir() {
+		header.Name += "/"
+	}
+
+	err = tw.WriteHeader(header)
+	if err != nil || !info.Mode().IsRegular() {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
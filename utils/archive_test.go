@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateTar(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"bitrix/a.php", "bitrix/.settings.php", "bitrix/cache/c.php", "upload/b.jpg"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	err := CreateTar(gw, root, []string{"bitrix"}, func(rel string) bool {
+		return rel == "bitrix/.settings.php" || rel == "bitrix/cache"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = gw.Close()
+
+	dest := t.TempDir()
+	if err = ExtractTar(buf, dest, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		exists bool
+	}{
+		{"bitrix/a.php", true},
+		{"bitrix/.settings.php", false},
+		{"bitrix/cache/c.php", false},
+		{"upload/b.jpg", false},
+	}
+	for _, tt := range tests {
+		if got := PathExists(filepath.Join(dest, filepath.FromSlash(tt.name))); got != tt.exists {
+			t.Errorf("%s exists = %v, want %v", tt.name, got, tt.exists)
+		}
+	}
+}
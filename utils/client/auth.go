@@ -1,10 +1,13 @@
 package client
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 
+	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
 )
@@ -47,6 +50,146 @@ func UseAgent() (Auth, error) {
 	}, nil
 }
 
+// keyAuth public key authentication with the ssh agent keys and the private key files.
+// The keys are loaded when the server asks for them, the passphrase of an encrypted key
+// is asked when the server accepts the key.
+type keyAuth struct {
+	files []string
+	// passphrase asks for the passphrase of the encrypted keys, nil if they are skipped
+	passphrase func() string
+	asked      *string
+	agent      net.Conn
+	loaded     []ssh.Signer
+	err        error
+}
+
+// signers agent keys first, hardware keys and password managers are available only through the agent.
+// The keys are loaded once, the jump hosts are authenticated with the same keys.
+func (k *keyAuth) signers() ([]ssh.Signer, error) {
+	if k.loaded == nil {
+		k.loaded = append(k.agentSigners(), k.fileSigners()...)
+	}
+	if len(k.loaded) == 0 {
+		if k.err == nil {
+			k.err = errors.New("no SSH keys found")
+		}
+		return nil, k.err
+	}
+
+	return k.loaded, nil
+}
+
+// agentSigners keys of the ssh agent, the agent connection stays open for signing
+func (k *keyAuth) agentSigners() []ssh.Signer {
+	if !HasAgent() {
+		return nil
+	}
+
+	conn, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
+	if err != nil {
+		logrus.Infof("SSH agent is not available: %s", err)
+		return nil
+	}
+
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		logrus.Infof("SSH agent is not available: %s", err)
+		_ = conn.Close()
+		return nil
+	}
+	k.agent = conn
+	logrus.Infof("SSH agent keys: %d", len(signers))
+
+	return signers
+}
+
+// fileSigners keys from the files, the missing and unreadable files are skipped
+func (k *keyAuth) fileSigners() []ssh.Signer {
+	var signers []ssh.Signer
+	for _, file := range k.files {
+		signer, err := k.fileSigner(file)
+		if err != nil {
+			logrus.Infof("Failed to load private key %s: %s", file, err)
+			k.err = err
+			continue
+		}
+		signers = append(signers, signer)
+	}
+
+	return signers
+}
+
+func (k *keyAuth) fileSigner(file string) (ssh.Signer, error) {
+	privateKey, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) || k.passphrase == nil {
+		return signer, err
+	}
+
+	// The public key of the old PEM format is encrypted too, the passphrase is needed to offer the key
+	if missing.PublicKey == nil {
+		return ssh.ParsePrivateKeyWithPassphrase(privateKey, []byte(k.getPassphrase()))
+	}
+
+	return &encryptedSigner{pub: missing.PublicKey, key: privateKey, auth: k}, nil
+}
+
+// getPassphrase the passphrase is asked once for all keys
+func (k *keyAuth) getPassphrase() string {
+	if k.asked == nil {
+		passphrase := k.passphrase()
+		k.asked = &passphrase
+	}
+
+	return *k.asked
+}
+
+// Close closing the agent connection
+func (k *keyAuth) Close() error {
+	if k.agent == nil {
+		return nil
+	}
+
+	return k.agent.Close()
+}
+
+// encryptedSigner encrypted key, it is decrypted on the first signing
+type encryptedSigner struct {
+	pub    ssh.PublicKey
+	key    []byte
+	auth   *keyAuth
+	signer ssh.AlgorithmSigner
+}
+
+func (s *encryptedSigner) PublicKey() ssh.PublicKey {
+	return s.pub
+}
+
+func (s *encryptedSigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	return s.SignWithAlgorithm(rand, data, "")
+}
+
+func (s *encryptedSigner) SignWithAlgorithm(rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	if s.signer == nil {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(s.key, []byte(s.auth.getPassphrase()))
+		if err != nil {
+			return nil, err
+		}
+		algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+		if !ok {
+			return nil, errors.New("ssh: the key does not support signature algorithms")
+		}
+		s.signer = algorithmSigner
+	}
+
+	return s.signer.SignWithAlgorithm(rand, data, algorithm)
+}
+
 // GetSigner returns ssh signer from private key file.
 func GetSigner(prvFile string, passphrase string) (ssh.Signer, error) {
 	var (
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func writeTestKey(t *testing.T, name string, passphrase string) string {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var block *pem.Block
+	if len(passphrase) > 0 {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(key, "", []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(key, "")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), name)
+	if err = os.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestKeyAuthPassphrase(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	asked := 0
+	keys := &keyAuth{
+		files: []string{
+			writeTestKey(t, "id_plain", ""),
+			writeTestKey(t, "id_encrypted", "secret"),
+			filepath.Join(t.TempDir(), "id_missing"),
+		},
+		passphrase: func() string {
+			asked++
+			return "secret"
+		},
+	}
+
+	signers, err := keys.signers()
+	if err != nil {
+		t.Fatalf("signers() error = %v", err)
+	}
+	if len(signers) != 2 {
+		t.Fatalf("signers() = %d keys, want 2", len(signers))
+	}
+	if asked != 0 {
+		t.Error("passphrase is asked before signing")
+	}
+
+	for i := 0; i < 2; i++ {
+		sig, err := signers[1].Sign(rand.Reader, []byte("data"))
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		if err = signers[1].PublicKey().Verify([]byte("data"), sig); err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+	}
+	if asked != 1 {
+		t.Errorf("passphrase asked %d times, want 1", asked)
+	}
+
+	keys = &keyAuth{files: []string{writeTestKey(t, "id_encrypted", "secret")}}
+	if _, err = keys.signers(); err == nil {
+		t.Error("signers() expected error for the encrypted key without passphrase")
+	}
+}
@@ -2,15 +2,21 @@ package client
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/local-deploy/dl/utils"
 	"github.com/pterm/pterm"
+	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/terminal"
 )
@@ -19,6 +25,10 @@ import (
 type Client struct {
 	*ssh.Client
 	Config *Config
+	// jumps connections to the ProxyJump hosts, they are closed with the client
+	jumps []*ssh.Client
+	// agent connection to the ssh agent, it is closed with the client
+	agent io.Closer
 }
 
 // Config for Client
@@ -34,23 +44,69 @@ type Config struct {
 	UseKeyPassphrase bool
 	Timeout          time.Duration
 	Callback         ssh.HostKeyCallback
+	// IdentityFiles private keys from ~/.ssh/config, they are tried before Key
+	IdentityFiles []string
+	// ProxyJump comma separated list of intermediate hosts ([user@]host[:port])
+	ProxyJump string
+	// HostKeyPolicy handling of unknown host keys: strict, accept-new or ask (default)
+	HostKeyPolicy string
+	// KnownHostsFile known hosts file instead of ~/.ssh/known_hosts
+	KnownHostsFile string
+	// ReadOnlyKnownHosts unknown hosts accepted by the policy are not added to the known hosts file
+	ReadOnlyKnownHosts bool
 }
 
 // defaultTimeout is the timeout of ssh client connection.
 var defaultTimeout = 20 * time.Second
 
-// NewClient returns new client and error if any
+// defaultPort is the port of ssh server if it is not set.
+const defaultPort = 22
+
+// NewClient returns new client and error if any.
+// The server address may be a Host alias from ~/.ssh/config, its HostName, User, Port, IdentityFile
+// and ProxyJump are used if they are not set in the config.
 func NewClient(config *Config) (c *Client, err error) {
-	c, err = newConn(&Config{
-		User:     config.User,
-		Addr:     config.Addr,
-		Port:     config.Port,
-		Catalog:  config.Catalog,
-		FwType:   config.FwType,
-		Timeout:  defaultTimeout,
-		Auth:     getAuth(config),
-		Callback: verifyHost,
-	})
+	callback, err := hostKeyCallback(config.HostKeyPolicy, config.KnownHostsFile, config.ReadOnlyKnownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	hc := LookupHost(config.Addr)
+
+	server := &Config{
+		User:             config.User,
+		Addr:             config.Addr,
+		Port:             config.Port,
+		Key:              config.Key,
+		Catalog:          config.Catalog,
+		FwType:           config.FwType,
+		UsePassword:      config.UsePassword,
+		UseKeyPassphrase: config.UseKeyPassphrase,
+		Timeout:          defaultTimeout,
+		Callback:         callback,
+		IdentityFiles:    hc.IdentityFiles,
+		ProxyJump:        hc.ProxyJump,
+	}
+	if len(hc.HostName) > 0 {
+		server.Addr = hc.HostName
+	}
+	if len(server.User) == 0 {
+		server.User = hc.User
+	}
+	if server.Port == 0 {
+		server.Port = hc.Port
+	}
+	if server.Port == 0 {
+		server.Port = defaultPort
+	}
+	auth, agent := getAuth(server)
+	server.Auth = auth
+
+	c, err = newConn(server)
+	c.agent = agent
+	if err != nil {
+		c.closeAgent()
+	}
 
 	return
 }
@@ -61,18 +117,118 @@ func newConn(config *Config) (c *Client, err error) {
 		Config: config,
 	}
 
-	c.Client, err = dial("tcp", config)
+	// Each next host is dialed through the previous one
+	var jump *ssh.Client
+	for _, hop := range jumpHosts(config) {
+		logrus.Infof("SSH connect via jump host %s:%d", hop.Addr, hop.Port)
+		jump, err = dial(jump, hop)
+		if err != nil {
+			c.closeJumps()
+			return c, fmt.Errorf("jump host %s: %w", hop.Addr, err)
+		}
+		c.jumps = append(c.jumps, jump)
+	}
+
+	c.Client, err = dial(jump, config)
+	if err != nil {
+		c.closeJumps()
+	}
 	return
 }
 
-// dial starts a client connection to SSH server based on config.
-func dial(proto string, c *Config) (*ssh.Client, error) {
-	return ssh.Dial(proto, net.JoinHostPort(c.Addr, fmt.Sprint(c.Port)), &ssh.ClientConfig{
+// dial starts a client connection to SSH server based on config, directly or through the jump host.
+func dial(jump *ssh.Client, c *Config) (*ssh.Client, error) {
+	addr := net.JoinHostPort(c.Addr, fmt.Sprint(c.Port))
+	config := &ssh.ClientConfig{
 		User:            c.User,
 		Auth:            c.Auth,
 		Timeout:         c.Timeout,
 		HostKeyCallback: c.Callback,
-	})
+	}
+
+	if jump == nil {
+		return ssh.Dial("tcp", addr, config)
+	}
+
+	conn, err := jump.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// jumpHosts configs of the ProxyJump hosts, the hosts may be aliases from ~/.ssh/config.
+// The jump hosts are authenticated in the same way as the server.
+func jumpHosts(config *Config) []*Config {
+	if len(config.ProxyJump) == 0 || strings.EqualFold(config.ProxyJump, "none") {
+		return nil
+	}
+
+	var hops []*Config
+	for _, hop := range strings.Split(config.ProxyJump, ",") {
+		hop = strings.TrimPrefix(strings.TrimSpace(hop), "ssh://")
+		user, host, found := strings.Cut(hop, "@")
+		if !found {
+			user, host = "", hop
+		}
+
+		var port uint
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			host = h
+			parsed, _ := strconv.ParseUint(p, 10, 16)
+			port = uint(parsed)
+		}
+
+		hc := LookupHost(host)
+		if len(hc.HostName) > 0 {
+			host = hc.HostName
+		}
+		if len(user) == 0 {
+			user = hc.User
+		}
+		if len(user) == 0 {
+			user = config.User
+		}
+		if port == 0 {
+			port = hc.Port
+		}
+		if port == 0 {
+			port = defaultPort
+		}
+
+		hops = append(hops, &Config{
+			User:     user,
+			Addr:     host,
+			Port:     port,
+			Auth:     config.Auth,
+			Timeout:  config.Timeout,
+			Callback: config.Callback,
+		})
+	}
+
+	return hops
+}
+
+// closeAgent closing the connection to the ssh agent
+func (c *Client) closeAgent() {
+	if c.agent != nil {
+		_ = c.agent.Close()
+	}
+}
+
+// closeJumps closing connections to the jump hosts in reverse order
+func (c *Client) closeJumps() {
+	for i := len(c.jumps) - 1; i >= 0; i-- {
+		_ = c.jumps[i].Close()
+	}
+	c.jumps = nil
 }
 
 // Run starts a new SSH session and runs the cmd, it returns CombinedOutput and err if any.
@@ -95,58 +251,135 @@ func (c Client) Run(cmd string) ([]byte, error) {
 	return sess.CombinedOutput(cmd)
 }
 
-func getAuth(config *Config) Auth {
-	if config.UsePassword {
-		auth := Password(askPass("Enter SSH Password: "))
+// RunWithStdin starts a new SSH session and runs the cmd reading stdin from the reader,
+// it returns CombinedOutput and err if any.
+func (c Client) RunWithStdin(cmd string, stdin io.Reader) ([]byte, error) {
+	sess, err := c.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer func(sess *ssh.Session) {
+		_ = sess.Close()
+	}(sess)
+
+	sess.Stdin = stdin
+	return sess.CombinedOutput(cmd)
+}
+
+// Stream output of the remote command
+type Stream struct {
+	io.Reader
+	sess   *ssh.Session
+	stderr *bytes.Buffer
+}
 
-		return auth
+// Stream starts a new SSH session and runs the cmd, the stdout of the command is read from the returned Stream.
+// The stream must be read to the end before calling Wait.
+func (c Client) Stream(cmd string) (RemoteStream, error) {
+	sess, err := c.NewSession()
+	if err != nil {
+		return nil, err
 	}
 
-	home, _ := utils.HomeDir()
-	auth, err := Key(filepath.Join(home, ".ssh", config.Key), getPassphrase(config.UseKeyPassphrase))
+	stdout, err := sess.StdoutPipe()
 	if err != nil {
-		pterm.FgRed.Println(err)
-		return nil
+		_ = sess.Close()
+		return nil, err
+	}
+
+	stderr := &bytes.Buffer{}
+	sess.Stderr = stderr
+
+	if err = sess.Start(cmd); err != nil {
+		_ = sess.Close()
+		return nil, err
 	}
-	return auth
+
+	return &Stream{Reader: stdout, sess: sess, stderr: stderr}, nil
 }
 
-func getPassphrase(ask bool) string {
-	if ask {
-		return askPass("Enter Private Key Passphrase: ")
+// Wait waits for the remote command to exit, the error contains stderr of the command
+func (s *Stream) Wait() error {
+	err := s.sess.Wait()
+	if err != nil && s.stderr.Len() > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(s.stderr.String()))
 	}
-	return ""
+
+	return err
 }
 
-func askPass(msg string) string {
-	fmt.Print(msg)
-	pass, err := terminal.ReadPassword(0)
-	if err != nil {
-		panic(err)
+// Close closes the session, the remote command is terminated if it is still running
+func (s *Stream) Close() error {
+	// Not all servers support signals: a command writing to the session stops when it writes to the closed session,
+	// a command writing to files keeps running, it must be killed by its PID
+	_ = s.sess.Signal(ssh.SIGTERM)
+
+	err := s.sess.Close()
+	if errors.Is(err, io.EOF) {
+		return nil
 	}
-	fmt.Println("")
 
-	return strings.TrimSpace(string(pass))
+	return err
 }
 
-func verifyHost(host string, remote net.Addr, key ssh.PublicKey) error {
-	hostFound, err := CheckKnownHost(host, remote, key, "")
+// getAuth authentication methods of the config and the closer of the ssh agent connection
+func getAuth(config *Config) (Auth, io.Closer) {
+	if config.UsePassword {
+		auth := Password(askPass("Enter SSH Password: "))
 
-	// Host in known hosts but key mismatch
-	if hostFound && err != nil {
-		return err
+		return auth, nil
 	}
 
-	// handshake because public key already exists
-	if hostFound {
-		return nil
+	// Keys from ~/.ssh/config are tried before SSH_KEY, as in OpenSSH
+	keyFiles := KeyFiles(config.Key)
+	keys := &keyAuth{}
+	for _, file := range append(config.IdentityFiles, keyFiles...) {
+		if slices.Contains(keyFiles, file) || utils.PathExists(file) {
+			keys.files = append(keys.files, file)
+		}
+	}
+	if config.UseKeyPassphrase {
+		keys.passphrase = func() string {
+			return askPass("Enter Private Key Passphrase: ")
+		}
 	}
 
-	if !askIsHostTrusted(host, key) {
-		pterm.FgRed.Println("Connection aborted")
-		return nil
+	// All keys are in one method: the ssh client does not try the same method twice
+	return Auth{ssh.PublicKeysCallback(keys.signers)}, keys
+}
+
+// KeyFiles paths of the private keys from the SSH_KEY variable: a comma separated list
+// of absolute paths, paths relative to the home directory (~/) or file names in ~/.ssh
+func KeyFiles(keys string) []string {
+	home, _ := utils.HomeDir()
+
+	var files []string
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		switch {
+		case len(key) == 0:
+			continue
+		case filepath.IsAbs(key):
+			files = append(files, key)
+		case strings.HasPrefix(key, "~/"):
+			files = append(files, filepath.Join(home, key[2:]))
+		default:
+			files = append(files, filepath.Join(home, ".ssh", key))
+		}
+	}
+
+	return files
+}
+
+func askPass(msg string) string {
+	fmt.Print(msg)
+	pass, err := terminal.ReadPassword(0)
+	if err != nil {
+		panic(err)
 	}
-	return AddKnownHost(host, remote, key, "")
+	fmt.Println("")
+
+	return strings.TrimSpace(string(pass))
 }
 
 func askIsHostTrusted(host string, key ssh.PublicKey) bool {
@@ -5,11 +5,97 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 
+	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
+// Host key policies
+const (
+	// HostKeyStrict unknown hosts are rejected
+	HostKeyStrict = "strict"
+	// HostKeyAcceptNew unknown hosts are added to the known hosts file without confirmation
+	HostKeyAcceptNew = "accept-new"
+	// HostKeyAsk confirmation is requested for unknown hosts
+	HostKeyAsk = "ask"
+)
+
+// HostKeyCallback returns host key callback checking the known hosts file according to the policy.
+// A changed key is always an error, an unknown host is handled by the policy. Empty knownFile is ~/.ssh/known_hosts.
+func HostKeyCallback(policy, knownFile string) (ssh.HostKeyCallback, error) {
+	return hostKeyCallback(policy, knownFile, false)
+}
+
+// hostKeyCallback with readOnly, the unknown hosts accepted by the policy are trusted
+// only for the current connection and are not added to the known hosts file
+func hostKeyCallback(policy, knownFile string, readOnly bool) (ssh.HostKeyCallback, error) {
+	switch policy {
+	case "":
+		policy = HostKeyAsk
+	case HostKeyStrict, HostKeyAcceptNew, HostKeyAsk:
+	default:
+		return nil, fmt.Errorf("unknown host key policy %q, expected %s, %s or %s", policy, HostKeyStrict, HostKeyAcceptNew, HostKeyAsk)
+	}
+
+	return func(host string, remote net.Addr, key ssh.PublicKey) error {
+		hostFound, err := CheckKnownHost(host, remote, key, knownFile)
+
+		// Host in known hosts but key mismatch, or the known hosts file is not readable
+		if err != nil {
+			return err
+		}
+
+		// handshake because public key already exists
+		if hostFound {
+			return nil
+		}
+
+		switch policy {
+		case HostKeyStrict:
+			return fmt.Errorf("host key verification failed: %s is not in the known hosts file (policy %s)", host, HostKeyStrict)
+		case HostKeyAsk:
+			if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+				return fmt.Errorf("host key verification failed: %s is unknown and confirmation is not possible without a terminal, "+
+					"use the %s policy or add the host to the known hosts file", host, HostKeyAcceptNew)
+			}
+			if !askIsHostTrusted(host, key) {
+				return errors.New("host key verification failed: connection aborted")
+			}
+		case HostKeyAcceptNew:
+			logrus.Infof("Host %s is accepted, fingerprint key: %s", host, ssh.FingerprintSHA256(key))
+		}
+
+		if readOnly {
+			logrus.Infof("Host %s is not added to the known hosts in read-only mode", host)
+			return nil
+		}
+
+		return AddKnownHost(host, remote, key, knownFile)
+	}, nil
+}
+
+// createKnownHostsFile creating an empty known hosts file and its directory if they do not exist
+func createKnownHostsFile(file string) error {
+	if _, err := os.Stat(file); err == nil {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(file), 0o700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
 // DefaultKnownHosts returns host key callback from default known hosts path, and error if any.
 func DefaultKnownHosts() (ssh.HostKeyCallback, error) {
 	path, err := DefaultKnownHostsPath()
@@ -36,8 +122,11 @@ func CheckKnownHost(host string, remote net.Addr, key ssh.PublicKey, knownFile s
 		knownFile = path
 	}
 
-	// Get host key callback
+	// Get host key callback, a missing file has no hosts: it is created when the first host is added
 	callback, err := KnownHosts(knownFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
 	if err != nil {
 		return false, err
 	}
@@ -52,8 +141,11 @@ func CheckKnownHost(host string, remote net.Addr, key ssh.PublicKey, knownFile s
 
 	// Make sure that the error returned from the callback is host not in file error.
 	// If keyErr.Want is greater than 0 length, that means host is in file with different key.
-	if errors.As(err, &keyErr) && len(keyErr.Want) > 0 {
-		return true, keyErr
+	if errors.As(err, &keyErr) {
+		if len(keyErr.Want) > 0 {
+			return true, keyErr
+		}
+		return false, nil
 	}
 
 	// Some other error occurred and safest way to handle is to pass it back to user.
@@ -77,6 +169,11 @@ func AddKnownHost(host string, remote net.Addr, key ssh.PublicKey, knownFile str
 		knownFile = path
 	}
 
+	err = createKnownHostsFile(knownFile)
+	if err != nil {
+		return err
+	}
+
 	f, err := os.OpenFile(knownFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
 	if err != nil {
 		return err
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 22}
+	key := newTestKey(t)
+	knownFile := filepath.Join(t.TempDir(), "known_hosts")
+
+	if _, err := HostKeyCallback("trust-all", knownFile); err == nil {
+		t.Error("HostKeyCallback() expected error for unknown policy")
+	}
+
+	strict, _ := HostKeyCallback(HostKeyStrict, knownFile)
+	if err := strict("192.0.2.10:22", remote, key); err == nil {
+		t.Error("strict policy accepted unknown host")
+	}
+
+	acceptNew, _ := HostKeyCallback(HostKeyAcceptNew, knownFile)
+	if err := acceptNew("192.0.2.10:22", remote, key); err != nil {
+		t.Errorf("accept-new policy error = %v", err)
+	}
+	if err := strict("192.0.2.10:22", remote, key); err != nil {
+		t.Errorf("strict policy rejected known host: %v", err)
+	}
+
+	if err := acceptNew("192.0.2.10:22", remote, newTestKey(t)); err == nil {
+		t.Error("accept-new policy accepted changed host key")
+	}
+}
+
+func TestHostKeyCallbackMissingDefault(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.11"), Port: 22}
+	key := newTestKey(t)
+
+	strict, _ := HostKeyCallback(HostKeyStrict, "")
+	if err := strict("192.0.2.11:22", remote, key); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("strict policy error = %v, want unknown host", err)
+	}
+
+	acceptNew, _ := HostKeyCallback(HostKeyAcceptNew, "")
+	if err := acceptNew("192.0.2.11:22", remote, key); err != nil {
+		t.Fatalf("accept-new policy error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".ssh", "known_hosts")); err != nil {
+		t.Errorf("known hosts file is not created: %v", err)
+	}
+	if err := strict("192.0.2.11:22", remote, key); err != nil {
+		t.Errorf("strict policy rejected known host: %v", err)
+	}
+}
+
+func TestHostKeyCallbackReadOnly(t *testing.T) {
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.12"), Port: 22}
+	knownFile := filepath.Join(t.TempDir(), "known_hosts")
+
+	acceptNew, _ := hostKeyCallback(HostKeyAcceptNew, knownFile, true)
+	if err := acceptNew("192.0.2.12:22", remote, newTestKey(t)); err != nil {
+		t.Fatalf("accept-new policy error = %v", err)
+	}
+	if _, err := os.Stat(knownFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("known hosts file is written in read-only mode: %v", err)
+	}
+}
@@ -2,15 +2,23 @@ package client
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/docker/compose/v2/pkg/progress"
 	"github.com/local-deploy/dl/utils"
 	"github.com/pkg/sftp"
 	"github.com/pterm/pterm"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
 // NewSftp returns new sftp client and error if any.
@@ -18,8 +26,8 @@ func (c Client) NewSftp(opts ...sftp.ClientOption) (*sftp.Client, error) {
 	return sftp.NewClient(c.Client, opts...)
 }
 
-// CleanRemote Deleting file on the server
-func (c Client) CleanRemote(remotePath string) (err error) {
+// Remove Deleting file on the server
+func (c Client) Remove(remotePath string) (err error) {
 	ftp, err := c.NewSftp()
 	if err != nil {
 		return err
@@ -38,51 +46,230 @@ func (c Client) CleanRemote(remotePath string) (err error) {
 	return err
 }
 
-// Download file from remote server
-//
-//goland:noinspection GoUnhandledErrorResult
-func (c Client) Download(ctx context.Context, remotePath, localPath string) (err error) {
-	// w := progress.ContextWriter(ctx)
-	local, err := os.Create(localPath)
-	if err != nil {
-		return
-	}
-	defer local.Close()
+// Download settings
+const (
+	downloadChunkSize = 2 * 1024 * 1024
+	downloadWorkers   = 8
+)
+
+// Download file from remote server.
+// The file is read in chunks concurrently and written to the "<localPath>.<size>-<mtime>.part" file,
+// a failed download of the same remote file is resumed from this file by the next call.
+// Progress events are sent with the id through progress.ContextWriter.
+func (c Client) Download(ctx context.Context, id, remotePath, localPath string) error {
+	w := progress.ContextWriter(ctx)
 
 	ftp, err := c.NewSftp()
 	if err != nil {
-		return
+		return err
 	}
 	defer ftp.Close()
 
 	fileInfo, err := ftp.Stat(remotePath)
 	if err != nil {
-		return
+		return err
+	}
+	size := fileInfo.Size()
+
+	partPath := fmt.Sprintf("%s.%d-%d.part", localPath, size, fileInfo.ModTime().Unix())
+	removeStaleParts(localPath, partPath)
+
+	local, hash, offset, err := openPart(partPath, size)
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+
+	// A resumed download is verified with the checksum of the whole file on the server, it is calculated
+	// while the rest of the file is downloading. A download from the beginning is only checked by the size.
+	remoteSum := make(chan string, 1)
+	if offset > 0 {
+		logrus.Infof("Resume download from %s: %s", utils.HumanSize(float64(offset)), remotePath)
+		go func() {
+			remoteSum <- c.remoteChecksum(remotePath)
+		}()
+	} else {
+		remoteSum <- ""
 	}
-	defer ftp.Close()
 
 	localDisk := utils.FreeSpaceHome()
-	if fileInfo.Size() > int64(localDisk.Free) {
-		remoteSize := utils.HumanSize(float64(fileInfo.Size()))
+	if size-offset > int64(localDisk.Free) {
+		remoteSize := utils.HumanSize(float64(size - offset))
 		localSize := utils.HumanSize(float64(localDisk.Free))
-		return errors.New(fmt.Sprintf("No disk space. Filesize %s, free space %s", remoteSize, localSize))
+		return fmt.Errorf("no disk space. Filesize %s, free space %s", remoteSize, localSize)
 	}
 
 	remote, err := ftp.Open(remotePath)
 	if err != nil {
-		return
+		return err
 	}
 	defer remote.Close()
 
-	if _, err = io.Copy(local, remote); err != nil {
-		return
+	start := time.Now()
+	started := offset
+	var last time.Time
+	err = readChunks(ctx, remote, offset, size, func(chunk []byte) error {
+		if _, err := local.Write(chunk); err != nil {
+			return err
+		}
+		hash.Write(chunk)
+		offset += int64(len(chunk))
+
+		if time.Since(last) >= 500*time.Millisecond || offset == size {
+			last = time.Now()
+			text := "Download: " + utils.FormatProgress(offset, size)
+			if eta := utils.FormatETA(offset-started, size-started, time.Since(start)); len(eta) > 0 {
+				text += ", ETA " + eta
+			}
+			w.Event(progress.Event{ID: id, StatusText: text})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = local.Sync(); err != nil {
+		return err
+	}
+	if err = verifyDownload(local, size, hex.EncodeToString(hash.Sum(nil)), <-remoteSum); err != nil {
+		_ = os.Remove(partPath)
+		return err
+	}
+	if err = local.Close(); err != nil {
+		return err
 	}
 
-	return local.Sync()
+	return os.Rename(partPath, localPath)
+}
+
+// openPart opening the partial download for writing after the downloaded beginning of the file,
+// returns the hash of the beginning and its size, the resume offset. A part larger than the file is truncated.
+func openPart(partPath string, size int64) (*os.File, hash.Hash, int64, error) {
+	local, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// Only a contiguous beginning of the file is written, so its size is the resume offset
+	h := sha256.New()
+	offset, err := io.Copy(h, local)
+	if err == nil && offset > size {
+		h.Reset()
+		offset = 0
+		err = local.Truncate(0)
+		if err == nil {
+			_, err = local.Seek(0, io.SeekStart)
+		}
+	}
+	if err != nil {
+		_ = local.Close()
+		return nil, nil, 0, err
+	}
+
+	return local, h, offset, nil
+}
+
+// removeStaleParts deleting partial downloads of previous versions of the remote file
+func removeStaleParts(localPath, partPath string) {
+	parts, _ := filepath.Glob(localPath + ".*.part")
+	for _, p := range parts {
+		if p != partPath {
+			logrus.Infof("Delete stale partial download: %s", p)
+			_ = os.Remove(p)
+		}
+	}
+}
+
+// readChunks reading the remote file from the offset with concurrent ReadAt requests,
+// the chunks are passed to the write function in order
+func readChunks(ctx context.Context, remote io.ReaderAt, offset, size int64, write func([]byte) error) error {
+	type chunk struct {
+		data []byte
+		err  error
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	// Each chunk has its own result channel, the queue keeps the order of chunks
+	queue := make(chan chan chunk, downloadWorkers)
+
+	g.Go(func() error {
+		defer close(queue)
+		sem := make(chan struct{}, downloadWorkers)
+		for off := offset; off < size; off += downloadChunkSize {
+			result := make(chan chunk, 1)
+			select {
+			case queue <- result:
+			case <-gctx.Done():
+				return gctx.Err()
+			}
+
+			sem <- struct{}{}
+			go func(off int64) {
+				defer func() { <-sem }()
+				buf := make([]byte, min(downloadChunkSize, size-off))
+				n, err := remote.ReadAt(buf, off)
+				if errors.Is(err, io.EOF) && n == len(buf) {
+					err = nil
+				}
+				result <- chunk{data: buf[:n], err: err}
+			}(off)
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		for result := range queue {
+			var c chunk
+			select {
+			case c = <-result:
+			case <-gctx.Done():
+				return gctx.Err()
+			}
+			if c.err != nil {
+				return c.err
+			}
+			if err := write(c.data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return g.Wait()
+}
+
+// remoteChecksum SHA-256 of the file on the server, empty if sha256sum is not available
+func (c Client) remoteChecksum(remotePath string) string {
+	out, err := c.Run("sha256sum " + utils.ShellQuote(remotePath))
+	if err != nil {
+		logrus.Infof("Failed to calculate checksum on the server: %s", err)
+		return ""
+	}
+
+	sum, _, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	return sum
+}
+
+// verifyDownload checking the size and checksum of the downloaded file
+func verifyDownload(local *os.File, size int64, localSum, remoteSum string) error {
+	info, err := local.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() != size {
+		return fmt.Errorf("downloaded file size %d does not match the remote size %d", info.Size(), size)
+	}
+
+	if len(remoteSum) > 0 && remoteSum != localSum {
+		return fmt.Errorf("checksum mismatch: local %s, remote %s", localSum, remoteSum)
+	}
+
+	return nil
 }
 
 // Upload a local file to remote server!
-func (c Client) Upload(localPath string, remotePath string) (err error) {
+func (c Client) Upload(ctx context.Context, localPath string, remotePath string) (err error) {
 	local, err := os.Open(localPath)
 	if err != nil {
 		return
@@ -101,11 +288,14 @@ func (c Client) Upload(localPath string, remotePath string) (err error) {
 	}
 	defer remote.Close()
 
-	_, err = io.Copy(remote, local)
+	_, err = io.Copy(remote, utils.NewContextReader(ctx, local))
 	return
 }
 
-// Close client net connection.
+// Close client net connection and connections to the jump hosts.
 func (c Client) Close() error {
-	return c.Client.Close()
+	err := c.Client.Close()
+	c.closeJumps()
+	c.closeAgent()
+	return err
 }
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadChunks(t *testing.T) {
+	data := make([]byte, downloadChunkSize*5+123)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	for _, offset := range []int64{0, downloadChunkSize + 7, int64(len(data))} {
+		got := &bytes.Buffer{}
+		err := readChunks(context.Background(), bytes.NewReader(data), offset, int64(len(data)), func(chunk []byte) error {
+			got.Write(chunk)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("readChunks() error = %v", err)
+		}
+		if !bytes.Equal(got.Bytes(), data[offset:]) {
+			t.Errorf("readChunks() from offset %d returned wrong data", offset)
+		}
+	}
+}
+
+func TestOpenPart(t *testing.T) {
+	partPath := filepath.Join(t.TempDir(), "dump.sql.gz.10-1.part")
+	if err := os.WriteFile(partPath, []byte("0123"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	local, _, offset, err := openPart(partPath, 10)
+	if err != nil {
+		t.Fatalf("openPart() error = %v", err)
+	}
+	_ = local.Close()
+	if offset != 4 {
+		t.Errorf("openPart() offset = %d, want 4", offset)
+	}
+
+	// The part is larger than the changed remote file, it is downloaded again from the beginning
+	local, _, offset, err = openPart(partPath, 3)
+	if err != nil {
+		t.Fatalf("openPart() error = %v", err)
+	}
+	_, _ = local.Write([]byte("abc"))
+	_ = local.Close()
+	if offset != 0 {
+		t.Errorf("openPart() offset = %d, want 0", offset)
+	}
+	if data, _ := os.ReadFile(partPath); string(data) != "abc" {
+		t.Errorf("part content = %q, want %q", data, "abc")
+	}
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/local-deploy/dl/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// HostConfig settings of the host from ~/.ssh/config
+type HostConfig struct {
+	HostName      string
+	User          string
+	Port          uint
+	IdentityFiles []string
+	ProxyJump     string
+}
+
+// sshConfigLine keyword and arguments of the ssh_config line
+type sshConfigLine struct {
+	key  string
+	args []string
+}
+
+// LookupHost settings of the host alias from ~/.ssh/config.
+// As in OpenSSH, the first obtained value of each parameter is used, Match blocks are ignored.
+func LookupHost(alias string) *HostConfig {
+	home, err := utils.HomeDir()
+	if err != nil {
+		return &HostConfig{}
+	}
+
+	lines, err := readSSHConfig(filepath.Join(home, ".ssh", "config"), home, 0)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.Infof("Failed to read ssh config: %s", err)
+		}
+		return &HostConfig{}
+	}
+
+	return lookupHost(lines, alias, home)
+}
+
+func lookupHost(lines []sshConfigLine, alias, home string) *HostConfig {
+	hc := &HostConfig{}
+	// Parameters before the first Host apply to all hosts
+	match := true
+	for _, line := range lines {
+		switch line.key {
+		case "host":
+			match = matchHost(line.args, alias)
+			continue
+		case "match":
+			match = false
+			continue
+		}
+		if !match || len(line.args) == 0 {
+			continue
+		}
+
+		value := line.args[0]
+		switch line.key {
+		case "hostname":
+			if len(hc.HostName) == 0 {
+				hc.HostName = strings.ReplaceAll(value, "%h", alias)
+			}
+		case "user":
+			if len(hc.User) == 0 {
+				hc.User = value
+			}
+		case "port":
+			if hc.Port == 0 {
+				port, _ := strconv.ParseUint(value, 10, 16)
+				hc.Port = uint(port)
+			}
+		case "identityfile":
+			hc.IdentityFiles = append(hc.IdentityFiles, expandHome(value, home))
+		case "proxyjump":
+			if len(hc.ProxyJump) == 0 {
+				hc.ProxyJump = value
+			}
+		}
+	}
+
+	return hc
+}
+
+// readSSHConfig reading the config file, Include directives are expanded
+func readSSHConfig(file, home string, depth int) ([]sshConfigLine, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseSSHConfig(f, func(pattern string) []sshConfigLine {
+		// Relative paths are relative to ~/.ssh, the nesting is limited as in OpenSSH
+		if depth >= 16 {
+			return nil
+		}
+		pattern = expandHome(pattern, home)
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(home, ".ssh", pattern)
+		}
+
+		var lines []sshConfigLine
+		files, _ := filepath.Glob(pattern)
+		for _, include := range files {
+			included, err := readSSHConfig(include, home, depth+1)
+			if err != nil {
+				logrus.Infof("Failed to read ssh config: %s", err)
+				continue
+			}
+			lines = append(lines, included...)
+		}
+		return lines
+	})
+}
+
+// parseSSHConfig parsing lines of the config, include returns the lines of the included files
+func parseSSHConfig(r io.Reader, include func(pattern string) []sshConfigLine) ([]sshConfigLine, error) {
+	var lines []sshConfigLine
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// "Key value" and "Key=value" forms are allowed
+		key, rest := line, ""
+		if i := strings.IndexAny(line, " \t="); i > 0 {
+			key = line[:i]
+			rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line[i:]), "="))
+		}
+
+		l := sshConfigLine{key: strings.ToLower(key), args: splitArgs(rest)}
+		if l.key == "include" {
+			for _, pattern := range l.args {
+				lines = append(lines, include(pattern)...)
+			}
+			continue
+		}
+		lines = append(lines, l)
+	}
+
+	return lines, scanner.Err()
+}
+
+// splitArgs splitting arguments by spaces, double quoted arguments may contain spaces
+func splitArgs(s string) []string {
+	var (
+		args   []string
+		cur    strings.Builder
+		quoted bool
+	)
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case (r == ' ' || r == '\t') && !quoted:
+			if cur.Len() > 0 {
+				args = append(args, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		args = append(args, cur.String())
+	}
+
+	return args
+}
+
+// matchHost checking the alias against Host patterns, a negated pattern excludes the host
+func matchHost(patterns []string, alias string) bool {
+	var matched bool
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		if ok, _ := filepath.Match(strings.TrimPrefix(pattern, "!"), alias); ok {
+			if negated {
+				return false
+			}
+			matched = true
+		}
+	}
+
+	return matched
+}
+
+func expandHome(path, home string) string {
+	if path == "~" {
+		return home
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(home, path[2:])
+	}
+
+	return path
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSSHConfig = `
+# Host * goes last, the first obtained value is used
+Host prod
+    HostName 10.0.0.5
+    User deploy
+    Port 2222
+    IdentityFile ~/.ssh/prod_key
+    ProxyJump bastion
+
+Host bastion
+    HostName=bastion.example.com
+    User jump
+
+Host *.example.com !secret.example.com
+    User web
+
+Host *
+    User root
+    IdentityFile "~/.ssh/id_ed25519"
+`
+
+func TestLookupHost(t *testing.T) {
+	lines, err := parseSSHConfig(strings.NewReader(testSSHConfig), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		alias string
+		want  HostConfig
+	}{
+		{
+			alias: "prod",
+			want: HostConfig{HostName: "10.0.0.5", User: "deploy", Port: 2222, ProxyJump: "bastion",
+				IdentityFiles: []string{"/home/user/.ssh/prod_key", "/home/user/.ssh/id_ed25519"}},
+		},
+		{
+			alias: "bastion",
+			want:  HostConfig{HostName: "bastion.example.com", User: "jump", IdentityFiles: []string{"/home/user/.ssh/id_ed25519"}},
+		},
+		{alias: "site.example.com", want: HostConfig{User: "web", IdentityFiles: []string{"/home/user/.ssh/id_ed25519"}}},
+		{alias: "secret.example.com", want: HostConfig{User: "root", IdentityFiles: []string{"/home/user/.ssh/id_ed25519"}}},
+	}
+
+	for _, tt := range tests {
+		if got := lookupHost(lines, tt.alias, "/home/user"); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("lookupHost(%s) = %+v, want %+v", tt.alias, *got, tt.want)
+		}
+	}
+}
+
+func TestJumpHosts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "config"), []byte("Host bastion\n    HostName bastion.example.com\n    User jump\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hops := jumpHosts(&Config{User: "deploy", ProxyJump: "admin@first.example.org:2200,second.example.org,bastion"})
+	if len(hops) != 3 {
+		t.Fatalf("jumpHosts() returned %d hosts, want 3", len(hops))
+	}
+	if hops[0].User != "admin" || hops[0].Addr != "first.example.org" || hops[0].Port != 2200 {
+		t.Errorf("first hop = %s@%s:%d", hops[0].User, hops[0].Addr, hops[0].Port)
+	}
+	if hops[1].User != "deploy" || hops[1].Addr != "second.example.org" || hops[1].Port != defaultPort {
+		t.Errorf("second hop = %s@%s:%d", hops[1].User, hops[1].Addr, hops[1].Port)
+	}
+	if hops[2].User != "jump" || hops[2].Addr != "bastion.example.com" {
+		t.Errorf("alias hop = %s@%s:%d", hops[2].User, hops[2].Addr, hops[2].Port)
+	}
+}
+
+func TestKeyFiles(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	got := KeyFiles("id_ed25519, /opt/keys/deploy,~/keys/stage,")
+	want := []string{"/home/user/.ssh/id_ed25519", "/opt/keys/deploy", "/home/user/keys/stage"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("KeyFiles() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"io"
+)
+
+// Transport access to the server: running commands and transferring files.
+// It is implemented by Client (SSH) and the Teleport client, the deploy is written once over it.
+type Transport interface {
+	// Run runs the cmd on the server, it returns CombinedOutput and err if any
+	Run(cmd string) ([]byte, error)
+	// Stream runs the cmd on the server, its stdout is read from the returned stream
+	Stream(cmd string) (RemoteStream, error)
+	// Download copies the remote file to the local path, progress events are sent with the id
+	Download(ctx context.Context, id, remotePath, localPath string) error
+	// Upload copies the local file to the remote path, it is stopped when ctx is cancelled
+	Upload(ctx context.Context, localPath, remotePath string) error
+	// Remove deletes the file on the server
+	Remove(remotePath string) error
+	// Settings of the connection: the site directory and the framework
+	Settings() *Config
+}
+
+// RemoteStream output of the command running on the server.
+// The stream must be read to the end before calling Wait.
+type RemoteStream interface {
+	io.Reader
+	// Wait waits for the command to exit, the error contains stderr of the command
+	Wait() error
+	// Close terminates the command if it is still running
+	Close() error
+}
+
+// Settings of the connection
+func (c Client) Settings() *Config {
+	return c.Config
+}
+
+var _ Transport = Client{}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestCompressDecompress(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "Plain", file: "dump.sql"},
+		{name: "Gzip", file: "dump.sql.gz"},
+		{name: "Zstd", file: "dump.sql.zst"},
+	}
+	data := []byte("INSERT INTO `b_user` VALUES (1,'admin');\n")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := Compress(buf, tt.file)
+			if err != nil {
+				t.Fatalf("Compress() error = %v", err)
+			}
+			_, _ = w.Write(data)
+			if err = w.Close(); err != nil {
+				t.Fatalf("Compress() close error = %v", err)
+			}
+
+			r, err := Decompress(buf)
+			if err != nil {
+				t.Fatalf("Decompress() error = %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("Decompress() read error = %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("Decompress() = %s, want %s", got, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/sirupsen/logrus"
+)
+
+// execInspectInterval interval of checking that the command in the container is finished
+const execInspectInterval = 50 * time.Millisecond
+
+// Exec running a command in the container.
+// The stdin is passed to the command input, the command output is written to stdout.
+// Returns an error with the command stderr if the exit code is not zero.
+func (cli *Client) Exec(ctx context.Context, container string, cmd, env []string, stdin io.Reader, stdout io.Writer) error {
+	client := cli.DockerCli.Client()
+
+	logrus.Infof("Run command in container %s: %s", container, strings.Join(cmd, " "))
+	exec, err := client.ContainerExecCreate(ctx, container, types.ExecConfig{
+		AttachStdin:  stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          env,
+		Cmd:          cmd,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	// Closing the connection interrupts the copying of the input and the output
+	stop := context.AfterFunc(ctx, func() {
+		resp.Close()
+	})
+	defer stop()
+
+	if stdout == nil {
+		stdout = io.Discard
+	}
+
+	stderr := &bytes.Buffer{}
+	outputDone := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(stdout, stderr, resp.Reader)
+		outputDone <- err
+	}()
+
+	var inputErr error
+	if stdin != nil {
+		_, inputErr = io.Copy(resp.Conn, stdin)
+		_ = resp.CloseWrite()
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	select {
+	case err = <-outputDone:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// The output may be closed before the exit code of the command is set
+	inspect, err := client.ContainerExecInspect(ctx, exec.ID)
+	for err == nil && inspect.Running {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(execInspectInterval):
+		}
+		inspect, err = client.ContainerExecInspect(ctx, exec.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("command exited with code %d: %s", inspect.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+
+	return inputErr
+}
@@ -0,0 +1,237 @@
+package utils
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsafePath archive entry pointing outside the destination directory
+var ErrUnsafePath = errors.New("unsafe path in archive")
+
+// ExtractTar extracting a tar archive (gzip and zstd are detected by the content) into the destination directory.
+// Entries with absolute paths, "../" or links pointing outside the destination are rejected.
+// The file modes are kept, the owner always has read and write access. fn is called for each extracted entry.
+// The archive is read to the end, a truncated or corrupted compressed stream is an error.
+func ExtractTar(r io.Reader, destination string, fn func(name string)) error {
+	dr, err := Decompress(r)
+	if err != nil {
+		return err
+	}
+	defer dr.Close()
+
+	destination, err = filepath.Abs(destination)
+	if err != nil {
+		return err
+	}
+	destination, err = filepath.EvalSymlinks(destination)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(dr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			// The rest of the archive is read, so that the gzip trailer (checksum and size) is verified
+			_, err = io.Copy(io.Discard, dr)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := safeJoin(destination, header.Name)
+		if err != nil {
+			return err
+		}
+
+		// The parent directories are created before and may be links from the archive
+		err = checkParent(destination, target)
+		if err != nil {
+			return fmt.Errorf("%s: %w", header.Name, err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, header.FileInfo().Mode().Perm()|0o700)
+		case tar.TypeReg:
+			err = extractFile(tr, target, header)
+		case tar.TypeSymlink:
+			err = extractSymlink(destination, target, header.Linkname)
+		case tar.TypeLink:
+			err = extractHardlink(destination, target, header.Linkname)
+		default:
+			// Devices, FIFOs and other special files are not needed in the project
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", header.Name, err)
+		}
+
+		if fn != nil {
+			fn(header.Name)
+		}
+	}
+}
+
+// MergeDir moving the contents of the src directory into the dst directory, existing files are replaced,
+// files missing in src are kept. Both directories must be on the same file system. src is removed.
+func MergeDir(src, dst string) error {
+	err := filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			info, err := os.Lstat(target)
+			if err == nil && info.IsDir() {
+				return nil
+			}
+			// A file or a link is replaced with the directory
+			if err == nil {
+				if err = os.Remove(target); err != nil {
+					return err
+				}
+			}
+			info, err = d.Info()
+			if err != nil {
+				return err
+			}
+			return os.Mkdir(target, info.Mode().Perm())
+		}
+
+		return replaceWith(target, func() error { return os.Rename(path, target) })
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(src)
+}
+
+// safeJoin joining the archive entry name with the destination, the result must be inside the destination
+func safeJoin(destination, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+
+	target := filepath.Join(destination, filepath.FromSlash(name))
+	if !isInside(destination, target) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+
+	return target, nil
+}
+
+// checkParent creating the parent directory of the target and checking that it does not lead outside the destination
+func checkParent(destination, target string) error {
+	parent := filepath.Dir(target)
+	err := os.MkdirAll(parent, 0o775)
+	if err != nil {
+		return err
+	}
+
+	real, err := filepath.EvalSymlinks(parent)
+	if err != nil {
+		return err
+	}
+	if !isInside(destination, real) {
+		return fmt.Errorf("%w: %s", ErrUnsafePath, target)
+	}
+
+	return nil
+}
+
+// isInside checking that the path is the directory itself or is inside it
+func isInside(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func extractFile(r io.Reader, target string, header *tar.Header) error {
+	// The existing file is removed so as not to write through a symlink or into a hardlinked file
+	var f *os.File
+	err := replaceWith(target, func() (err error) {
+		f, err = os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, header.FileInfo().Mode().Perm()|0o600)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(target, header.FileInfo().Mode().Perm()|0o600)
+	if err != nil {
+		return err
+	}
+
+	return os.Chtimes(target, header.ModTime, header.ModTime)
+}
+
+func extractSymlink(destination, target, link string) error {
+	resolved := link
+	if !filepath.IsAbs(link) {
+		parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+		if err != nil {
+			return err
+		}
+		resolved = filepath.Join(parent, filepath.FromSlash(link))
+	}
+	if !isInside(destination, resolved) {
+		return fmt.Errorf("%w: link to %s", ErrUnsafePath, link)
+	}
+
+	return replaceWith(target, func() error { return os.Symlink(link, target) })
+}
+
+// extractHardlink creating the hardlink to the extracted file. The source is resolved through the symlinks,
+// which may lead outside the destination even if the link name looks safe.
+func extractHardlink(destination, target, link string) error {
+	source, err := safeJoin(destination, link)
+	if err != nil {
+		return err
+	}
+
+	real, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return err
+	}
+	if !isInside(destination, real) {
+		return fmt.Errorf("%w: hardlink to %s", ErrUnsafePath, link)
+	}
+
+	return replaceWith(target, func() error { return os.Link(real, target) })
+}
+
+// replaceWith removing the existing file and creating a new one
+func replaceWith(target string, create func() error) error {
+	if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+		if err = os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	return create()
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type tarEntry struct {
+	name, link string
+	typeflag   byte
+	mode       int64
+}
+
+func makeTarGz(t *testing.T, entries []tarEntry) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Linkname: e.link, Typeflag: e.typeflag, Mode: e.mode}
+		if e.typeflag == tar.TypeReg {
+			h.Size = int64(len(e.name))
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if e.typeflag == tar.TypeReg {
+			_, _ = tw.Write([]byte(e.name))
+		}
+	}
+	_ = tw.Close()
+	_ = gw.Close()
+
+	return buf
+}
+
+func TestExtractTar(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		unsafe  bool
+	}{
+		{
+			name: "Regular files",
+			entries: []tarEntry{
+				{name: "bitrix/", typeflag: tar.TypeDir, mode: 0o755},
+				{name: "bitrix/index.php", typeflag: tar.TypeReg, mode: 0o640},
+				{name: "bitrix/link.php", link: "index.php", typeflag: tar.TypeSymlink},
+			},
+		},
+		{name: "Parent directory", entries: []tarEntry{{name: "../evil.php", typeflag: tar.TypeReg, mode: 0o644}}, unsafe: true},
+		{name: "Absolute path", entries: []tarEntry{{name: "/tmp/evil.php", typeflag: tar.TypeReg, mode: 0o644}}, unsafe: true},
+		{name: "Absolute symlink", entries: []tarEntry{{name: "etc", link: "/etc", typeflag: tar.TypeSymlink}}, unsafe: true},
+		{
+			name: "Symlink chain",
+			entries: []tarEntry{
+				{name: "a", link: ".", typeflag: tar.TypeSymlink},
+				{name: "a/b", link: "..", typeflag: tar.TypeSymlink},
+			},
+			unsafe: true,
+		},
+		{
+			name: "Hardlink through symlink chain",
+			entries: []tarEntry{
+				{name: "a", link: ".", typeflag: tar.TypeSymlink},
+				{name: "b", link: "a/a/..", typeflag: tar.TypeSymlink},
+				{name: "c", link: "a/b/..", typeflag: tar.TypeSymlink},
+				{name: "victim", link: "c/victim.txt", typeflag: tar.TypeLink},
+				{name: "victim", typeflag: tar.TypeReg, mode: 0o644},
+			},
+			unsafe: true,
+		},
+		{
+			name: "Regular file over hardlink",
+			entries: []tarEntry{
+				{name: "index.php", typeflag: tar.TypeReg, mode: 0o644},
+				{name: "bitrix/link.php", link: "index.php", typeflag: tar.TypeLink},
+				{name: "bitrix/link.php", typeflag: tar.TypeReg, mode: 0o640},
+				{name: "bitrix/index.php", typeflag: tar.TypeReg, mode: 0o640},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The destination is two levels below the file that must stay unchanged
+			root := t.TempDir()
+			victim := filepath.Join(root, "victim.txt")
+			if err := os.WriteFile(victim, []byte("outside"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			dest := filepath.Join(root, "one", "two")
+			if err := os.MkdirAll(dest, 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ExtractTar(makeTarGz(t, tt.entries), dest, nil)
+			if content, _ := os.ReadFile(victim); string(content) != "outside" {
+				t.Errorf("ExtractTar() changed the file outside the destination: %q", content)
+			}
+			if tt.unsafe {
+				if !errors.Is(err, ErrUnsafePath) {
+					t.Errorf("ExtractTar() error = %v, want ErrUnsafePath", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractTar() error = %v", err)
+			}
+
+			info, err := os.Stat(filepath.Join(dest, "bitrix", "link.php"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0o640 {
+				t.Errorf("ExtractTar() mode = %v, want 0640", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestMergeDir(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(src, "bitrix", "index.php"):       "new",
+		filepath.Join(src, "bitrix", "php", "init.php"): "new",
+		filepath.Join(dst, "bitrix", "index.php"):       "old",
+		filepath.Join(dst, "bitrix", "local.php"):       "local",
+		filepath.Join(dst, "upload", "image.jpg"):       "local",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := MergeDir(src, dst); err != nil {
+		t.Fatalf("MergeDir() error = %v", err)
+	}
+
+	want := map[string]string{
+		"bitrix/index.php":    "new",
+		"bitrix/php/init.php": "new",
+		"bitrix/local.php":    "local",
+		"upload/image.jpg":    "local",
+	}
+	for name, content := range want {
+		got, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+
+	if PathExists(src) {
+		t.Error("MergeDir() source directory is not removed")
+	}
+}
+
+func TestExtractTarTruncated(t *testing.T) {
+	data := makeTarGz(t, []tarEntry{{name: "a.txt", typeflag: tar.TypeReg, mode: 0o644}}).Bytes()
+
+	// Without the gzip trailer the tar entries are complete, but the archive is not
+	err := ExtractTar(bytes.NewReader(data[:len(data)-8]), t.TempDir(), nil)
+	if err == nil {
+		t.Error("ExtractTar() expected error for the archive without the gzip trailer")
+	}
+
+	if err = ExtractTar(bytes.NewReader(data), t.TempDir(), nil); err != nil {
+		t.Errorf("ExtractTar() error = %v", err)
+	}
+}
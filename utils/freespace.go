@@ -77,6 +77,10 @@ func HumanSize(size float64) string {
 	suffixes[3] = "GB"
 	suffixes[4] = "TB"
 
+	if size < 1 {
+		return "0 B"
+	}
+
 	base := math.Log(size) / math.Log(1024)
 	getSize := round(math.Pow(1024, base-math.Floor(base)), .5, 1)
 	getSuffix := suffixes[int(math.Floor(base))]
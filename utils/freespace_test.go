@@ -33,6 +33,7 @@ func TestHumanSize(t *testing.T) {
 		{name: "Convert bytes to KB", args: args{size: 46164}, want: "45.1 KB"},
 		{name: "Convert bytes to MB", args: args{size: 5987456}, want: "5.7 MB"},
 		{name: "Convert bytes to GB", args: args{size: 3459834587}, want: "3.2 GB"},
+		{name: "Zero bytes", args: args{size: 0}, want: "0 B"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -18,9 +18,12 @@ func HomeDir() (string, error) {
 	return os.UserHomeDir()
 }
 
+// UserConfigDir user config directory, replaced in the tests
+var UserConfigDir = os.UserConfigDir
+
 // ConfigDir config directory (~/.config/dl)
 func ConfigDir() string {
-	conf, err := os.UserConfigDir()
+	conf, err := UserConfigDir()
 	if err != nil {
 		pterm.FgRed.Println(err)
 		os.Exit(1)
@@ -231,3 +234,8 @@ func CleanSlice(s []string) []string {
 	}
 	return r
 }
+
+// ShellQuote quoting the string for the shell
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
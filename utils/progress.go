@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// progressInterval minimum interval between progress notifications
+const progressInterval = 500 * time.Millisecond
+
+// ProgressReader reader that reports the number of bytes read
+type ProgressReader struct {
+	io.Reader
+	total      int64
+	read       int64
+	last       time.Time
+	onProgress func(read, total int64)
+}
+
+// NewProgressReader returns a reader that calls onProgress while reading, total is 0 if the size is unknown
+func NewProgressReader(r io.Reader, total int64, onProgress func(read, total int64)) *ProgressReader {
+	return &ProgressReader{Reader: r, total: total, onProgress: onProgress}
+}
+
+// Read reads data and reports progress no more often than progressInterval
+func (r *ProgressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.read += int64(n)
+
+	if time.Since(r.last) >= progressInterval || err == io.EOF {
+		r.last = time.Now()
+		r.onProgress(r.read, r.total)
+	}
+
+	return n, err
+}
+
+// BytesRead number of bytes read
+func (r *ProgressReader) BytesRead() int64 {
+	return r.read
+}
+
+// ProgressWriter writer that reports the number of bytes written
+type ProgressWriter struct {
+	io.Writer
+	written    int64
+	last       time.Time
+	onProgress func(written int64)
+}
+
+// NewProgressWriter returns a writer that calls onProgress while writing
+func NewProgressWriter(w io.Writer, onProgress func(written int64)) *ProgressWriter {
+	return &ProgressWriter{Writer: w, onProgress: onProgress}
+}
+
+// Write writes data and reports progress no more often than progressInterval
+func (w *ProgressWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.written += int64(n)
+
+	if time.Since(w.last) >= progressInterval {
+		w.last = time.Now()
+		w.onProgress(w.written)
+	}
+
+	return n, err
+}
+
+// FormatProgress human friendly progress, for example "120 MB / 1.2 GB (10%)"
+func FormatProgress(current, total int64) string {
+	if total <= 0 {
+		return HumanSize(float64(current))
+	}
+
+	return fmt.Sprintf("%s / %s (%d%%)", HumanSize(float64(current)), HumanSize(float64(total)), current*100/total)
+}
+
+// FormatETA remaining time estimated by the average speed, for example "1m30s"
+func FormatETA(done, total int64, elapsed time.Duration) string {
+	if done <= 0 || total <= done || elapsed <= 0 {
+		return ""
+	}
+
+	remaining := time.Duration(float64(elapsed) * float64(total-done) / float64(done))
+
+	return remaining.Round(time.Second).String()
+}
+
+// FormatAgo elapsed time since the moment, for example "3 days ago"
+func FormatAgo(t, now time.Time) string {
+	elapsed := now.Sub(t)
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return plural(int(elapsed/time.Minute), "minute")
+	case elapsed < 24*time.Hour:
+		return plural(int(elapsed/time.Hour), "hour")
+	default:
+		return plural(int(elapsed/(24*time.Hour)), "day")
+	}
+}
+
+// contextReader reader that stops when the context is cancelled
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// NewContextReader returns a reader that returns the context error after the context is cancelled
+func NewContextReader(ctx context.Context, r io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, r: r}
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
@@ -1,23 +1,33 @@
 package teleport
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
-	"strconv"
+	"path/filepath"
 	"strings"
 
-	"github.com/local-deploy/dl/project"
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/local-deploy/dl/utils"
+	"github.com/local-deploy/dl/utils/client"
 	"github.com/m7shapan/njson"
+	"github.com/sirupsen/logrus"
 )
 
-type teleport struct {
-	Proxy   string
-	User    string
-	Node    string
-	Catalog string
+// Client Teleport connection to the node, commands are run with "tsh ssh" and files are copied with "tsh scp"
+type Client struct {
+	Proxy  string
+	User   string
+	Node   string
+	config *client.Config
+	tsh    string
 }
 
+var _ client.Transport = (*Client)(nil)
+
 type status struct {
 	Cluster string   `njson:"active.cluster"`
 	Logins  []string `njson:"active.logins"`
@@ -29,135 +39,164 @@ type node struct {
 
 type nodes []node
 
-var s status
-var nn nodes
-
-func getClient() (t *teleport, err error) {
-	err = setTeleportStatus()
+// NewClient returns the client of the node from the TELEPORT variable ("login:node").
+// The user must be logged in with tsh and have access to the node and the login.
+// ctx stops the tsh commands checking the access.
+func NewClient(ctx context.Context, target, catalog string) (*Client, error) {
+	tsh, err := exec.LookPath("tsh")
 	if err != nil {
-		return nil, err
+		return nil, errors.New("teleport not installed")
 	}
 
-	err = setTeleportNodes()
-	if err != nil {
-		return nil, err
+	login, host, found := strings.Cut(target, ":")
+	if !found {
+		return nil, fmt.Errorf("invalid TELEPORT value %q, expected login:node", target)
 	}
 
-	env := project.Env.GetString("TELEPORT")
-	u := strings.Split(env, ":")
-
-	c := &teleport{
-		Proxy:   s.Cluster,
-		User:    u[0],
-		Node:    u[1],
-		Catalog: project.Env.GetString("CATALOG_SRV"),
+	s, err := getStatus(ctx, tsh)
+	if err != nil {
+		return nil, err
 	}
 
-	err = checkAccess(c)
+	nn, err := getNodes(ctx, tsh)
 	if err != nil {
 		return nil, err
 	}
 
-	return c, err
-}
+	c := &Client{
+		Proxy:  s.Cluster,
+		User:   login,
+		Node:   host,
+		config: &client.Config{User: login, Addr: host, Catalog: catalog},
+		tsh:    tsh,
+	}
 
-func checkAccess(c *teleport) error {
-	if accessNode(c) && accessUser(c) {
-		return nil
+	if !accessNode(nn, c.Node) || !accessUser(s, c.User) {
+		return nil, errors.New("you do not have access to this server")
 	}
-	return fmt.Errorf("You do not have access to this server")
+
+	return c, nil
 }
 
-func accessNode(c *teleport) bool {
+func accessNode(nn nodes, name string) bool {
 	for _, v := range nn {
-		if v.Node == c.Node {
+		if v.Node == name {
 			return true
 		}
 	}
 	return false
 }
 
-func accessUser(c *teleport) bool {
+func accessUser(s *status, login string) bool {
 	for _, l := range s.Logins {
-		if l == c.User {
+		if l == login {
 			return true
 		}
 	}
 	return false
 }
 
-func setTeleportStatus() error {
-	cmdStatus := []string{tsh, "status", "-f", "json"}
-	out, err := exec.Command("bash", "-c", strings.Join(cmdStatus[:], " ")).CombinedOutput()
+func getStatus(ctx context.Context, tsh string) (*status, error) {
+	out, err := exec.CommandContext(ctx, tsh, "status", "-f", "json").CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("the user is not authorized in Teleport")
+		return nil, errors.New("the user is not authorized in Teleport")
 	}
 
-	err = njson.Unmarshal(out, &s)
+	s := &status{}
+	err = njson.Unmarshal(out, s)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return s, nil
 }
 
-func setTeleportNodes() error {
-	cmdLs := []string{tsh, "ls", "-f", "json"}
-	out, err := exec.Command("bash", "-c", strings.Join(cmdLs[:], " ")).CombinedOutput()
+func getNodes(ctx context.Context, tsh string) (nodes, error) {
+	out, err := exec.CommandContext(ctx, tsh, "ls", "-f", "json").CombinedOutput()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var result []json.RawMessage
 	err = json.Unmarshal(out, &result)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var n node
+	var nn nodes
 	for _, val := range result {
+		var n node
 		err = njson.Unmarshal(val, &n)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		nn = append(nn, n)
 	}
-	return nil
+	return nn, nil
+}
+
+// Settings of the connection
+func (t *Client) Settings() *client.Config {
+	return t.config
 }
 
-func teleportBin() (string, error) {
-	t, err := exec.LookPath("tsh")
+// Run runs the cmd on the node, it returns CombinedOutput and err if any.
+// Run has no context: the cleanup commands are run after the deploy is cancelled,
+// long commands are run with Stream and stopped with Close.
+func (t *Client) Run(cmd string) ([]byte, error) {
+	return exec.Command(t.tsh, "ssh", t.User+"@"+t.Node, cmd).CombinedOutput()
+}
+
+// Stream runs the cmd on the node, its stdout is read from the returned stream, Close kills tsh
+func (t *Client) Stream(cmd string) (client.RemoteStream, error) {
+	c := exec.Command(t.tsh, "ssh", t.User+"@"+t.Node, cmd)
+	stdout, err := c.StdoutPipe()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return t, nil
-}
 
-func (t *teleport) run(cmd string) (string, error) {
-	cmdRun := []string{tsh, "ssh", t.User + "@" + t.Node, strconv.Quote(cmd)}
-	out, err := exec.Command("bash", "-c", strings.Join(cmdRun[:], " ")).CombinedOutput()
+	s := &stream{cmd: c, stderr: &bytes.Buffer{}}
+	s.Reader = stdout
+	c.Stderr = s.stderr
+
+	err = c.Start()
 	if err != nil {
-		return "", fmt.Errorf("Something went wrong")
+		return nil, err
 	}
 
-	return string(out), nil
+	return s, nil
+}
+
+// Download copies the remote file to the local path
+func (t *Client) Download(ctx context.Context, id, remotePath, localPath string) error {
+	w := progress.ContextWriter(ctx)
+	w.Event(progress.Event{ID: id, StatusText: "Download " + filepath.Base(remotePath)})
+
+	logrus.Infof("Download %s to %s", remotePath, localPath)
+	return t.scp(ctx, t.Node+":"+remotePath, localPath)
+}
+
+// Upload copies the local file to the remote path
+func (t *Client) Upload(ctx context.Context, localPath, remotePath string) error {
+	logrus.Infof("Upload %s to %s", localPath, remotePath)
+	return t.scp(ctx, localPath, t.Node+":"+remotePath)
 }
 
-func (t *teleport) download(from, to string) error {
-	cmdRun := []string{tsh, "scp", "--login=" + t.User, t.Node + ":" + from, to}
-	_, err := exec.Command("bash", "-c", strings.Join(cmdRun[:], " ")).CombinedOutput()
+// Remove deletes the file on the node
+func (t *Client) Remove(remotePath string) error {
+	logrus.Infof("Delete file: %s", remotePath)
+	out, err := t.Run("rm -f " + utils.ShellQuote(remotePath))
 	if err != nil {
-		return fmt.Errorf("Something went wrong")
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
 	}
 
 	return nil
 }
 
-func (t *teleport) delete(path string) error {
-	cmdRun := []string{tsh, "ssh", t.User + "@" + t.Node, strconv.Quote("rm " + path)}
-	_, err := exec.Command("bash", "-c", strings.Join(cmdRun[:], " ")).CombinedOutput()
+func (t *Client) scp(ctx context.Context, from, to string) error {
+	out, err := exec.CommandContext(ctx, t.tsh, "scp", "--login="+t.User, from, to).CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("Something went wrong")
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
 	}
 
 	return nil
@@ -0,0 +1,47 @@
+package teleport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// stream output of the "tsh ssh" command
+type stream struct {
+	io.Reader
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+	// the command is waited once, Close may be called while Wait is waiting
+	once sync.Once
+	err  error
+}
+
+// wait waiting for the command once, the concurrent calls return the same result
+func (s *stream) wait() error {
+	s.once.Do(func() {
+		s.err = s.cmd.Wait()
+	})
+
+	return s.err
+}
+
+// Wait waits for the command to exit, the error contains stderr of the command
+func (s *stream) Wait() error {
+	err := s.wait()
+	if err != nil && s.stderr.Len() > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(s.stderr.String()))
+	}
+
+	return err
+}
+
+// Close terminates the command if it is still running
+func (s *stream) Close() error {
+	// The exited process is not killed again
+	_ = s.cmd.Process.Kill()
+	_ = s.wait()
+	return nil
+}
@@ -0,0 +1,34 @@
+package teleport
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestStreamClose(t *testing.T) {
+	c := exec.Command("sleep", "10")
+	s := &stream{cmd: c, stderr: &bytes.Buffer{}}
+	c.Stderr = s.stderr
+	if err := c.Start(); err != nil {
+		t.Skip(err)
+	}
+
+	waited := make(chan error, 1)
+	go func() {
+		waited <- s.Wait()
+	}()
+
+	_ = s.Close()
+	_ = s.Close()
+
+	select {
+	case err := <-waited:
+		if err == nil {
+			t.Error("Wait() error = nil, want the killed command error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait() is not finished after Close()")
+	}
+}